@@ -2,8 +2,11 @@ package abc
 
 import (
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
 	"sync"
+	"text/template"
 )
 
 var (
@@ -16,6 +19,8 @@ func init() {
 }
 
 // Root returns the globally used root logger.
+// It is not part of the registry of GetLogger, unless it is set to
+// the root of the registry with SetRoot(GetLogger("")).
 func Root() Logger {
 	return root
 }
@@ -28,6 +33,19 @@ func SetRoot(lg Logger) {
 	root = lg
 }
 
+// Shutdown syncs the root logger, if it implements Syncer,
+// which all loggers of this package do, so that no output is lost
+// when the application exits.
+// An AsyncLogger writes all queued records before it is synced.
+//
+//	defer abc.Shutdown()
+func Shutdown() error {
+	if s, ok := Root().(Syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
 // NewSimpleLogger returns a new abc.SimpleLogger,
 // which is ready to use.
 // The default log level is INFO and can be changed with
@@ -39,11 +57,9 @@ func SetRoot(lg Logger) {
 //
 //	logger.SetOut(os.Stdout)
 func NewSimpleLogger() WriterLogger {
-	return &SimpleLogger{
-		lvl: LevelInfo,
-		clk: &realClock{},
-		out: os.Stdout,
-	}
+	logger := &SimpleLogger{}
+	logger.configure(LevelInfo, &realClock{}, os.Stdout, simpleFormatter{})
+	return logger
 }
 
 // NewNamedLogger returns a new abc.NamedLogger,
@@ -56,21 +72,25 @@ func NewSimpleLogger() WriterLogger {
 // The output writer can be changed with
 //
 //	logger.SetOut(os.Stdout)
+//
+// The logger is the root of a hierarchy of named loggers, whose
+// children inherit its level and writer, see NamedLogger.Child.
+// The hierarchy is not part of the registry of GetLogger, so its
+// loggers are neither listed by Loggers nor matched by SetLevels.
 func NewNamedLogger(name string) WriterLogger {
-	return &NamedLogger{
-		lvl:  LevelInfo,
-		clk:  &realClock{},
-		out:  os.Stdout,
-		name: name,
-	}
+	logger := &NamedLogger{}
+	logger.configure(LevelInfo, &realClock{}, os.Stdout, namedFormatter{})
+	logger.setName(name)
+	return logger
 }
 
 // NewCustomPatternLogger returns a new abc.CustomPatternLogger,
 // which was initialized and thus is ready to use.
-// If an error occurs during the initialization, that error is
-// returned.
+// If the pattern is invalid, a *PatternError with the position
+// of the error is returned, together with a logger that uses
+// the CustomPatternLoggerDefaultPattern.
 //
-// The given pattern is a go template text and supports the
+// The given pattern uses the syntax of go templates and supports the
 // following operations:
 //
 //	{{.Level}} // the level of the message
@@ -81,6 +101,10 @@ func NewNamedLogger(name string) WriterLogger {
 // If the message or the pattern doesn't end with a line break,
 // no line break will be printed.
 //
+//	{{.Fields}} // the fields that were attached with With
+// Fields prints the fields of the logger in the form key=value,
+// separated by spaces.
+//
 //	{{.Timestamp}} or {{.Timestampf "2006-01-02 03:04:05PM"}} // time.Time.Format's layout is used
 // Timestampf takes a string argument, which will be used for formatting
 // the timestamp in the log message. The reference time is the same as in
@@ -99,6 +123,43 @@ func NewNamedLogger(name string) WriterLogger {
 // will print <package>.<function>.
 // Functionf "full" will print <full_package>.<function>, e.g. "github.com/TimSatke/abc.main".
 //
+//	{{.Name}} // the name of the logger
+//	{{.Field "key"}} // the value of the field with the given key
+//	{{.Hostname}} // the host name of the machine
+//	{{.PID}} // the id of the process
+//	{{.GoroutineID}} // the id of the goroutine of the log call
+// GoroutineID prints the goroutine that formats the message,
+// which is not the goroutine of the log call if the logger
+// is wrapped in an AsyncLogger.
+//
+//	{{.Seq}} // a sequence number, starting at 1
+// Seq counts the messages of the logger and all loggers
+// that were derived from it with With. The count continues,
+// when the pattern is replaced with SetPattern.
+//
+//	{{.Elapsed}} // the milliseconds since the start of the process
+//
+// The output of a verb can be passed through functions, either
+// with a pipeline like {{.Level | pad -5}} or with a call
+// like {{pad -5 .Level}}.
+// The following functions are builtin:
+//
+//	{{.Message | pad 20}} // pads on the left to at least 20 characters, pad -20 pads on the right
+//	{{.Message | trunc 20}} // keeps the first 20 characters, trunc -20 keeps the last 20 characters
+//	{{.Message | upper}} or {{.Message | lower}} // converts to upper or lower case
+//	{{color .Level}} or {{.Message | color}} // prints in the color of the level
+//
+// The colors are taken from the color theme of the logger,
+// and are only written, if a color mode is set with
+// CustomPatternLogger.SetColorMode.
+//
+// All other constructs of go templates, like {{if}}, {{with}},
+// variables and the functions of text/template, e.g. printf, can
+// be used as well. Patterns that use them are executed with
+// text/template, which is slower. Errors that occur while such a
+// pattern is executed, e.g. for an unknown verb in {{if .Foo}},
+// are passed to CustomPatternLogger.OnPatternError.
+//
 // Example:
 //
 //	{{.Timestamp}} {{.Filef "short"}}:{{.Line}} {{.Functionf "package"}} [{{.Level}}] - {{.Message}}\n
@@ -108,27 +169,261 @@ func NewNamedLogger(name string) WriterLogger {
 //	2018-11-24 15:26:44.453 main.go:16 main.main [INFO] - Hello World!
 //	<line break>
 func NewCustomPatternLogger(pattern string) (WriterLogger, error) {
-	logger := &CustomPatternLogger{
-		lvl:     LevelInfo,
-		clk:     &realClock{},
-		out:     os.Stdout,
-		pattern: pattern,
+	return NewCustomPatternLoggerWithFuncs(pattern, nil)
+}
+
+// NewCustomPatternLoggerWithFuncs returns a new abc.CustomPatternLogger
+// like NewCustomPatternLogger, whose pattern can call the given
+// functions in addition to the builtin functions, e.g.
+//
+//	abc.NewCustomPatternLoggerWithFuncs("{{.Level | lower}} {{env \"USER\"}} - {{.Message}}\n", template.FuncMap{
+//		"env": os.Getenv,
+//	})
+//
+// A function must return one value, or one value and an error.
+// Constant arguments are converted to the types of the parameters.
+// Verbs and the output of a previous command in a pipeline
+// are passed as strings.
+// The given functions override builtin functions with the same name.
+func NewCustomPatternLoggerWithFuncs(pattern string, funcs template.FuncMap) (WriterLogger, error) {
+	return newCustomPatternLogger(pattern, CustomPatternLoggerDefaultPattern, func(pattern string) (*compiledPattern, error) {
+		return compileTemplatePattern(pattern, funcs)
+	})
+}
+
+// NewConversionPatternLogger returns a new abc.CustomPatternLogger,
+// that uses a conversion pattern as known from log4j and logback
+// instead of a go template, e.g.
+//
+//	%d{yyyy-MM-dd HH:mm:ss.SSS} [%-5p] %c{1} %F:%L - %m%n
+//
+// If the pattern is invalid, a *PatternError with the position
+// of the error is returned, together with a logger that uses
+// the CustomPatternLoggerDefaultConversionPattern.
+//
+// The following conversion words are supported:
+//
+//	%d{format} or %date{format} // the timestamp
+// The format is a date format of java.text.SimpleDateFormat,
+// or one of ISO8601 (the default), ABSOLUTE and DATE.
+// It can be followed by a comma and a time zone, e.g. %d{HH:mm:ss.SSS, UTC}.
+// An unknown time zone is an error.
+//
+//	%p, %le or %level // the level of the message, e.g. INFO
+//	%c{n}, %lo{n} or %logger{n} // the name of the logger
+// If n is given, only the last n dot separated parts of the name are printed.
+//
+//	%F or %file // the file name of the caller
+//	%L or %line // the line of the caller
+//	%M or %method // the function name of the caller
+//	%l // the location of the caller, e.g. main.main(main.go:16)
+//	%m, %msg or %message // the message
+//	%n // a line break
+//	%X{key} or %mdc{key} // the value of the field with the given key
+// Without a key, all fields are printed in the form key=value.
+//
+//	%% // a percent sign
+//
+// Between the percent sign and the conversion word, a format modifier
+// can pad and truncate the output.
+//
+//	%5p // pads the level with spaces on the left to at least 5 characters
+//	%-5p // pads the level with spaces on the right
+//	%.10c // truncates the name at the beginning to at most 10 characters
+//	%.-10c // truncates the name at the end
+//	%-5.5p // pads and truncates the level to exactly 5 characters
+func NewConversionPatternLogger(pattern string) (WriterLogger, error) {
+	return newCustomPatternLogger(pattern, CustomPatternLoggerDefaultConversionPattern, compileConversionPattern)
+}
+
+// NewJSONLogger returns a new abc.JSONLogger,
+// which is ready to use.
+// The logger prints one JSON object per line.
+// The default log level is INFO and can be changed with
+//
+//	logger.SetLevel(abc.LevelInfo)
+//
+// The logger prints to os.Stdout by default.
+// The output writer can be changed with
+//
+//	logger.SetOut(os.Stdout)
+func NewJSONLogger() WriterLogger {
+	logger := &JSONLogger{}
+	logger.configure(LevelInfo, &realClock{}, os.Stdout, jsonFormatter{})
+	logger.setRecordsCaller(true)
+	return logger
+}
+
+// NewLogfmtLogger returns a new abc.LogfmtLogger,
+// which is ready to use.
+// The logger prints one line of logfmt key/value pairs per message.
+// The default log level is INFO and can be changed with
+//
+//	logger.SetLevel(abc.LevelInfo)
+//
+// The logger prints to os.Stdout by default.
+// The output writer can be changed with
+//
+//	logger.SetOut(os.Stdout)
+func NewLogfmtLogger() WriterLogger {
+	logger := &LogfmtLogger{}
+	logger.configure(LevelInfo, &realClock{}, os.Stdout, logfmtFormatter{})
+	return logger
+}
+
+// NewFormatterLogger returns a new abc.FormatterLogger,
+// which is ready to use.
+// The logger formats every message with the given formatter
+// and writes it to its output writer.
+// The default log level is INFO and can be changed with
+//
+//	logger.SetLevel(abc.LevelInfo)
+//
+// The logger prints to os.Stdout by default.
+// The output writer can be changed with
+//
+//	logger.SetOut(os.Stdout)
+func NewFormatterLogger(formatter Formatter) WriterLogger {
+	logger := &FormatterLogger{}
+	logger.configure(LevelInfo, &realClock{}, os.Stdout, formatter)
+	logger.setRecordsCaller(true)
+	return logger
+}
+
+// NewHandlerLogger returns a new abc.HandlerLogger,
+// which is ready to use.
+// The logger passes a record for every message to the
+// given handler.
+// The default log level is INFO and can be changed with
+//
+//	logger.SetLevel(abc.LevelInfo)
+func NewHandlerLogger(handler Handler) Logger {
+	logger := &HandlerLogger{}
+	logger.SetLevel(LevelInfo)
+	logger.SetClock(&realClock{})
+	logger.setRecordsCaller(true)
+	logger.setHandler(handler)
+	return logger
+}
+
+// NewSlogHandler returns a new slog.Handler, that passes all
+// records of a slog.Logger to the given logger, e.g.
+//
+//	slog.SetDefault(slog.New(abc.NewSlogHandler(abc.NewSimpleLogger())))
+//
+// The slog levels are mapped with FromSlogLevel, and the handler
+// is enabled for a level if and only if the given logger is.
+// See SlogHandler for details.
+func NewSlogHandler(logger Logger) slog.Handler {
+	return &SlogHandler{
+		logger: logger,
+	}
+}
+
+// NewSlogLogger returns a new abc.HandlerLogger, that passes all
+// messages to the given slog.Handler, e.g.
+//
+//	logger := abc.NewSlogLogger(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{AddSource: true}), nil)
+//
+// The LogLevels are mapped with the given mapping, or with ToSlogLevel
+// if the mapping is nil.
+// The default log level is VERBOSE, so that the slog.Handler decides which
+// messages are printed. It can be changed with
+//
+//	logger.SetLevel(abc.LevelInfo)
+//
+// The PC of every log call is passed to the handler, so that the
+// source of the slog records points to the caller of the logger.
+func NewSlogLogger(handler slog.Handler, mapping SlogLevelMapping) Logger {
+	if mapping == nil {
+		mapping = ToSlogLevel
 	}
-	err := logger.init()
-	return logger, err
+
+	logger := NewHandlerLogger(&slogHandler{
+		handler: handler,
+		mapping: mapping,
+	})
+	logger.SetLevel(LevelVerbose)
+	return logger
 }
 
 // NewColoredLogger creates a wrapper for a given WriterLogger.
 // Depending on the level that should be printed, this wrapper
-// will prepend an ANSI-color code to the wrapped loggers
-// output writer and will then call the respective output method.
-// Please notice that this function does not add a decorator to
-// the given logger, but creates a wrapper, which must be used
-// for colors to show up.
+// surrounds the output of the wrapped logger with an ANSI-color
+// code and the color code reset, and writes all of it with a
+// single write to the output writer of the returned logger, which
+// initially is the output writer of the wrapped logger.
+// By default, color codes are only written if the output writer
+// is a terminal, see ColoredLogger.
+// The given logger is not modified.
 func NewColoredLogger(wrapped WriterLogger) WriterLogger {
-	return &ColoredLogger{
-		wrapped: wrapped,
-	}
+	return newColoredLogger(wrapped, wrapped.Out(), defaultTheme, ColorModeAuto)
+}
+
+// NewAsyncLogger creates a wrapper for a given WriterLogger,
+// that queues all records and passes them to the wrapped
+// logger in a background goroutine.
+// The returned logger must be closed to write all queued records.
+//
+//	logger := abc.NewAsyncLogger(abc.NewSimpleLogger(), abc.AsyncOptions{
+//		QueueSize: 4096,
+//		Overflow:  abc.OverflowDropBelowLevel,
+//		DropBelow: abc.LevelWarn,
+//	})
+//	defer logger.Close()
+func NewAsyncLogger(wrapped WriterLogger, opts AsyncOptions) *AsyncLogger {
+	return newAsyncLogger(wrapped, opts, &realClock{})
+}
+
+// NewLevelAdminHandler returns an http.Handler, with which the
+// loggers of the registry, see GetLogger, can be inspected and their
+// levels can be changed at runtime. The paths are relative to the
+// handler, so it should be mounted with http.StripPrefix, e.g.
+//
+//	mux.Handle("/debug/loggers/", http.StripPrefix("/debug/loggers", abc.NewLevelAdminHandler()))
+//
+// It serves the following requests, whose responses are JSON:
+//
+//	GET /            // lists all loggers with their levels
+//	GET /app.db      // returns the logger app.db
+//	PUT /app.db      // sets the level of app.db to the level of the body, e.g. {"level": "debug"}
+//	DELETE /app.db   // ends the temporary level of app.db
+//
+// A logger looks like
+//
+//	{"name": "app.db", "level": "debug", "inherited": false, "expires": "2018-11-24T20:10:55Z"}
+//
+// where inherited is whether the level is inherited from the parent,
+// and expires is only present while the logger has a temporary level.
+// If the body of a PUT request has a duration like
+// {"level": "debug", "duration": "10m"}, the level is temporary,
+// and the previous level is restored after the duration, unless the
+// level was changed otherwise in the meantime.
+// Loggers, that are not in the registry yet, are not created.
+// The root of the registry is served by its RootLoggerName, e.g.
+// PUT /root.
+func NewLevelAdminHandler() http.Handler {
+	return newLevelAdmin(&realClock{})
+}
+
+// NewRotatingFile opens the file at the given path for appending
+// and returns a writer, that rotates the file when it exceeds the
+// maximum size or when a new interval of the given options starts.
+// The file and its directory are created if they don't exist.
+// The returned writer can be used as output of any WriterLogger.
+//
+//	file, err := abc.NewRotatingFile("logs/app-2006-01-02.log", abc.RotatingFileOptions{
+//		MaxSize:  10 * 1024 * 1024,
+//		Interval: abc.RotateDaily,
+//		MaxAge:   30 * 24 * time.Hour,
+//		Compress: true,
+//	})
+//	logger.SetOut(file)
+//
+// If the options contain an interval, and the file name of the path
+// does not change between two intervals, an error is returned.
+func NewRotatingFile(path string, opts RotatingFileOptions) (*RotatingFile, error) {
+	return newRotatingFile(path, opts, &realClock{})
 }
 
 // must panics, if the given error is not nil.
@@ -223,6 +518,17 @@ func Fatalf(format string, v ...interface{}) {
 	Printf(LevelFatal, format, v...)
 }
 
+// With returns a new logger derived from the root logger,
+// that prints the given key/value pairs with every message.
+// See FieldLogger.With for details.
+// If the root logger is no FieldLogger, it is returned unchanged.
+func With(keyvals ...interface{}) Logger {
+	if fl, ok := root.(FieldLogger); ok {
+		return fl.With(keyvals...)
+	}
+	return root
+}
+
 // SetLevel sets a new log level for the root logger.
 func SetLevel(lvl LogLevel) {
 	root.SetLevel(lvl)
@@ -23,22 +23,14 @@ func TestSetRoot(t *testing.T) {
 	defer SetRoot(temp) // cleanup
 
 	// no output during tests
-	l := &SimpleLogger{
-		clk: &mockClock{},
-		lvl: LevelInfo,
-		out: ioutil.Discard,
-	}
+	l := newTestSimpleLogger(LevelInfo, ioutil.Discard)
 	SetRoot(l)
 
 	Info("foo") // should write into ioutil.Discard
 
 	buf := &bytes.Buffer{}
 
-	logger := &SimpleLogger{
-		clk: &mockClock{},
-		lvl: LevelInfo,
-		out: buf,
-	}
+	logger := newTestSimpleLogger(LevelInfo, buf)
 	SetRoot(logger) // set new root logger
 
 	Info("abc")
@@ -57,11 +49,11 @@ func TestNewSimpleLogger(t *testing.T) {
 	assert.True(ok, "Logger was expected to be of type *SimpleLogger, but was not.")
 
 	// check default level
-	assert.Equal(logger.lvl, LevelInfo, "Expected level to be INFO")
+	assert.Equal(logger.Level(), LevelInfo, "Expected level to be INFO")
 	// check default out
-	assert.Equal(logger.out, os.Stdout)
+	assert.Equal(logger.Out(), os.Stdout)
 	// check default clock type (must be real clock)
-	_, ok = logger.clk.(*realClock)
+	_, ok = logger.clock().(*realClock)
 	assert.True(ok, "Clock was expected to be of type *realClock, but was not.")
 }
 
@@ -77,14 +69,14 @@ func TestNewNamedLogger(t *testing.T) {
 	assert.True(ok, "Logger was expected to be of type *NamedLogger, but was not.")
 
 	// check default level
-	assert.Equal(logger.lvl, LevelInfo, "Expected level to be INFO")
+	assert.Equal(logger.Level(), LevelInfo, "Expected level to be INFO")
 	// check default out
-	assert.Equal(logger.out, os.Stdout)
+	assert.Equal(logger.Out(), os.Stdout)
 	// check default clock type (must be real clock)
-	_, ok = logger.clk.(*realClock)
+	_, ok = logger.clock().(*realClock)
 	assert.True(ok, "Clock was expected to be of type *realClock, but was not.")
 	// check name
-	assert.Equalf(logger.name, name, "Expected name of logger to be '%v', but was '%v'.", name, logger.name)
+	assert.Equalf(logger.getName(), name, "Expected name of logger to be '%v', but was '%v'.", name, logger.getName())
 }
 
 func TestNewCustomPatternLogger(t *testing.T) {
@@ -100,14 +92,50 @@ func TestNewCustomPatternLogger(t *testing.T) {
 	assert.True(ok, "Logger was expected to be of type *CustomPatternLogger, but was not.")
 
 	// check default level
-	assert.Equal(logger.lvl, LevelInfo, "Expected level to be INFO")
+	assert.Equal(logger.Level(), LevelInfo, "Expected level to be INFO")
 	// check default out
-	assert.Equal(logger.out, os.Stdout)
+	assert.Equal(logger.Out(), os.Stdout)
 	// check default clock type (must be real clock)
-	_, ok = logger.clk.(*realClock)
+	_, ok = logger.clock().(*realClock)
 	assert.True(ok, "Clock was expected to be of type *realClock, but was not.")
 	// check pattern
-	assert.Equalf(logger.pattern, pattern, "Expected pattern of logger to be '%v', but was '%v'.", pattern, logger.pattern)
+	assert.Equalf(logger.Pattern(), pattern, "Expected pattern of logger to be '%v', but was '%v'.", pattern, logger.Pattern())
+}
+
+func TestNewJSONLogger(t *testing.T) {
+	assert := assert.New(t)
+
+	l := NewJSONLogger()
+
+	// check type
+	logger, ok := l.(*JSONLogger)
+	assert.True(ok, "Logger was expected to be of type *JSONLogger, but was not.")
+
+	// check default level
+	assert.Equal(logger.Level(), LevelInfo, "Expected level to be INFO")
+	// check default out
+	assert.Equal(logger.Out(), os.Stdout)
+	// check default clock type (must be real clock)
+	_, ok = logger.clock().(*realClock)
+	assert.True(ok, "Clock was expected to be of type *realClock, but was not.")
+}
+
+func TestNewLogfmtLogger(t *testing.T) {
+	assert := assert.New(t)
+
+	l := NewLogfmtLogger()
+
+	// check type
+	logger, ok := l.(*LogfmtLogger)
+	assert.True(ok, "Logger was expected to be of type *LogfmtLogger, but was not.")
+
+	// check default level
+	assert.Equal(logger.Level(), LevelInfo, "Expected level to be INFO")
+	// check default out
+	assert.Equal(logger.Out(), os.Stdout)
+	// check default clock type (must be real clock)
+	_, ok = logger.clock().(*realClock)
+	assert.True(ok, "Clock was expected to be of type *realClock, but was not.")
 }
 
 func TestNewColoredLogger(t *testing.T) {
@@ -145,11 +173,7 @@ func Test_All_Outputs(t *testing.T) {
 
 	buf := &bytes.Buffer{}
 
-	logger := &SimpleLogger{
-		clk: &mockClock{},
-		lvl: LevelVerbose,
-		out: buf,
-	}
+	logger := newTestSimpleLogger(LevelVerbose, buf)
 	SetRoot(logger)
 
 	check := func() {
@@ -195,7 +219,7 @@ func Test_All_Outputs(t *testing.T) {
 
 func TestMustNoPanic(t *testing.T) {
 	l := NewSimpleLogger()
-	foo := Must(l, nil)
+	foo := must(l, nil)
 	assert.Equal(l, foo, "Must must return the passed logger")
 }
 
@@ -206,7 +230,7 @@ func TestMustWithPanic(t *testing.T) {
 		}
 	}()
 
-	_ = Must(nil, errors.New("This error was panicked intentionally"))
+	_ = must(nil, errors.New("This error was panicked intentionally"))
 }
 
 func TestSetLevel(t *testing.T) {
@@ -217,11 +241,7 @@ func TestSetLevel(t *testing.T) {
 
 	buf := &bytes.Buffer{}
 
-	logger := &SimpleLogger{
-		clk: &mockClock{},
-		lvl: LevelInfo,
-		out: buf,
-	}
+	logger := newTestSimpleLogger(LevelInfo, buf)
 	SetRoot(logger) // set new root logger
 
 	Info("abc")
@@ -0,0 +1,457 @@
+package abc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultAsyncQueueSize is the number of records that an
+	// AsyncLogger queues, if no queue size is given.
+	DefaultAsyncQueueSize = 1024
+	// DefaultAsyncDropReportInterval is the interval in which an
+	// AsyncLogger reports dropped records, if no interval is given.
+	DefaultAsyncDropReportInterval = 10 * time.Second
+)
+
+// OverflowPolicy decides what an AsyncLogger does with a record,
+// if its queue is full.
+type OverflowPolicy uint8
+
+// Available overflow policies.
+const (
+	// OverflowBlock blocks the caller until there is room in the queue.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the record that should be queued.
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest record in the queue
+	// to make room for the record that should be queued.
+	OverflowDropOldest
+	// OverflowDropBelowLevel drops the record that should be queued,
+	// if its level is lower than AsyncOptions.DropBelow, and blocks
+	// the caller otherwise.
+	OverflowDropBelowLevel
+)
+
+// AsyncOptions configure an AsyncLogger.
+type AsyncOptions struct {
+	// QueueSize is the maximum number of queued records.
+	// If QueueSize is 0 or less, DefaultAsyncQueueSize is used.
+	QueueSize int
+	// Overflow decides what happens with a record if the queue is full.
+	Overflow OverflowPolicy
+	// DropBelow is the lowest level that is never dropped,
+	// if Overflow is OverflowDropBelowLevel.
+	DropBelow LogLevel
+	// DropReportInterval is the interval in which the number of
+	// records, that were dropped since the last report, is logged
+	// with level WARN, even if the wrapped logger has a higher level.
+	// If DropReportInterval is 0, DefaultAsyncDropReportInterval is used.
+	// If it is less than 0, dropped records are only reported on
+	// Flush and Close.
+	DropReportInterval time.Duration
+}
+
+// AsyncLogger is a wrapper for any WriterLogger, that logs
+// in a background goroutine.
+// Output methods only build a record, which contains the time and
+// the caller of the log call, and queue it, so the caller does not
+// wait for a slow writer.
+// The background goroutine passes the queued records to the
+// wrapped logger in the order in which they were queued.
+//
+// If the queue is full, the OverflowPolicy of the options decides,
+// whether the caller blocks or a record is dropped.
+// Dropped records are counted, and the number of dropped records
+// is logged periodically.
+//
+// Flush waits until all queued records are written, and Close
+// drains the queue, stops the background goroutine and closes
+// the wrapped logger.
+// Records that are logged after Close are passed to the wrapped
+// logger synchronously.
+//
+// The level of an AsyncLogger is the level of the wrapped logger.
+// AsyncLoggers are completely safe for concurrent use.
+type AsyncLogger struct {
+	core
+
+	queue *asyncQueue
+}
+
+// newAsyncLogger creates a new AsyncLogger, that uses the given
+// clock for the timestamps of its records and to report dropped records.
+func newAsyncLogger(wrapped WriterLogger, opts AsyncOptions, clk clock) *AsyncLogger {
+	logger := &AsyncLogger{queue: newAsyncQueue(wrapped, opts, clk)}
+	// the level is checked by the queue
+	logger.core.SetLevel(LevelVerbose)
+	logger.SetClock(clk)
+	logger.setRecordsCaller(true)
+	logger.setHandler(logger.queue)
+	return logger
+}
+
+// asyncItem is an element of the queue of an AsyncLogger.
+// It is either a record or, if flushed is not nil,
+// a flush request.
+type asyncItem struct {
+	rec     *Record
+	flushed chan struct{}
+}
+
+// asyncQueue is the queue and the background goroutine of an
+// AsyncLogger, that are shared with all loggers derived with With.
+type asyncQueue struct {
+	wrapped WriterLogger
+	opts    AsyncOptions
+	clk     clock
+
+	// closedMux is held for reading while items are queued,
+	// and for writing while the queue is closed.
+	closedMux sync.RWMutex
+	closed    bool
+	items     chan asyncItem
+	done      chan struct{}
+
+	dropped  uint64
+	reported uint64
+
+	// flushesMux guards flushes, which are flush requests that
+	// were taken from the queue to make room for a record.
+	flushesMux sync.Mutex
+	flushes    []chan struct{}
+}
+
+func newAsyncQueue(wrapped WriterLogger, opts AsyncOptions, clk clock) *asyncQueue {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultAsyncQueueSize
+	}
+	if opts.DropReportInterval == 0 {
+		opts.DropReportInterval = DefaultAsyncDropReportInterval
+	}
+
+	q := &asyncQueue{
+		wrapped: wrapped,
+		opts:    opts,
+		clk:     clk,
+		items:   make(chan asyncItem, opts.QueueSize),
+		done:    make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Enabled returns true if and only if the wrapped logger
+// has the given level enabled.
+func (q *asyncQueue) Enabled(lvl LogLevel) bool {
+	return q.wrapped.IsLevelEnabled(lvl)
+}
+
+// Handle queues a copy of the given record according to the
+// overflow policy.
+// If the queue is closed, the record is logged synchronously.
+func (q *asyncQueue) Handle(rec *Record) error {
+	r := *rec
+
+	q.closedMux.RLock()
+	defer q.closedMux.RUnlock()
+
+	if q.closed {
+		return logRecord(q.wrapped, &r)
+	}
+
+	item := asyncItem{rec: &r}
+	switch q.opts.Overflow {
+	case OverflowDropNewest:
+		q.tryEnqueue(item)
+	case OverflowDropOldest:
+		for !q.send(item) {
+			select {
+			case old := <-q.items:
+				if old.flushed != nil {
+					// flush requests are never dropped, but answered by
+					// the background goroutine after the record it
+					// currently passes to the wrapped logger
+					q.deferFlush(old.flushed)
+					continue
+				}
+				atomic.AddUint64(&q.dropped, 1)
+			default:
+			}
+		}
+	case OverflowDropBelowLevel:
+		if r.Level < q.opts.DropBelow {
+			q.tryEnqueue(item)
+		} else {
+			q.items <- item
+		}
+	default:
+		q.items <- item
+	}
+	return nil
+}
+
+// tryEnqueue queues the given item, if there is room in the queue.
+// Otherwise, the item is counted as dropped.
+func (q *asyncQueue) tryEnqueue(item asyncItem) {
+	if !q.send(item) {
+		atomic.AddUint64(&q.dropped, 1)
+	}
+}
+
+// send queues the given item and returns true, if there is
+// room in the queue, and returns false otherwise.
+func (q *asyncQueue) send(item asyncItem) bool {
+	select {
+	case q.items <- item:
+		return true
+	default:
+		return false
+	}
+}
+
+// run passes all queued records to the wrapped logger, answers
+// flush requests and reports dropped records, until the queue
+// is closed.
+func (q *asyncQueue) run() {
+	defer close(q.done)
+
+	var tick <-chan time.Time
+	if q.opts.DropReportInterval > 0 {
+		tick = q.clk.After(q.opts.DropReportInterval)
+	}
+
+	for {
+		select {
+		case item, ok := <-q.items:
+			if !ok {
+				q.reportDropped()
+				q.answerFlushes()
+				return
+			}
+			if item.flushed != nil {
+				q.reportDropped()
+				close(item.flushed)
+				continue
+			}
+			_ = logRecord(q.wrapped, item.rec)
+			q.answerFlushes()
+		case <-tick:
+			q.reportDropped()
+			tick = q.clk.After(q.opts.DropReportInterval)
+		}
+	}
+}
+
+// deferFlush remembers the given flush request, which was taken
+// from the queue, so that it is answered by the background goroutine.
+func (q *asyncQueue) deferFlush(flushed chan struct{}) {
+	q.flushesMux.Lock()
+	q.flushes = append(q.flushes, flushed)
+	q.flushesMux.Unlock()
+}
+
+// answerFlushes answers all flush requests, that were taken from
+// the queue. All records, that were queued before them, were
+// taken from the queue as well, and are passed to the wrapped
+// logger at this point.
+func (q *asyncQueue) answerFlushes() {
+	q.flushesMux.Lock()
+	flushes := q.flushes
+	q.flushes = nil
+	q.flushesMux.Unlock()
+
+	if len(flushes) == 0 {
+		return
+	}
+	q.reportDropped()
+	for _, flushed := range flushes {
+		close(flushed)
+	}
+}
+
+// anyLevelLogger is implemented by the loggers of this package,
+// which can log records regardless of their level.
+type anyLevelLogger interface {
+	logAnyLevel(*Record) error
+}
+
+// reportDropped logs the number of records that were dropped
+// since the last report, if any.
+// The report is logged regardless of the level of the wrapped
+// logger, if it is a logger of this package.
+func (q *asyncQueue) reportDropped() {
+	dropped := atomic.LoadUint64(&q.dropped)
+	if dropped == q.reported {
+		return
+	}
+
+	rec := &Record{
+		Time:    q.clk.Now(),
+		Level:   LevelWarn,
+		Message: fmt.Sprintf("dropped %v log messages, because the queue was full", dropped-q.reported),
+	}
+	if l, ok := q.wrapped.(anyLevelLogger); ok {
+		_ = l.logAnyLevel(rec)
+	} else {
+		_ = logRecord(q.wrapped, rec)
+	}
+	q.reported = dropped
+}
+
+// flush waits until all records, that were queued before,
+// are passed to the wrapped logger.
+func (q *asyncQueue) flush() {
+	q.closedMux.RLock()
+	if q.closed {
+		q.closedMux.RUnlock()
+		return
+	}
+	flushed := make(chan struct{})
+	q.items <- asyncItem{flushed: flushed}
+	q.closedMux.RUnlock()
+
+	<-flushed
+}
+
+// close drains the queue and stops the background goroutine.
+func (q *asyncQueue) close() {
+	q.closedMux.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.items)
+	}
+	q.closedMux.Unlock()
+
+	<-q.done
+}
+
+// Flush blocks until all records, that were logged before,
+// are passed to the wrapped logger.
+func (a *AsyncLogger) Flush() {
+	a.queue.flush()
+}
+
+// Sync flushes the queue and syncs the wrapped logger,
+// if it implements Syncer.
+func (a *AsyncLogger) Sync() error {
+	a.queue.flush()
+	if s, ok := a.queue.wrapped.(Syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Close passes all queued records to the wrapped logger,
+// stops the background goroutine and closes the wrapped logger,
+// if it implements io.Closer.
+// Close affects all loggers that were derived from this logger with With.
+func (a *AsyncLogger) Close() error {
+	a.queue.close()
+	if c, ok := a.queue.wrapped.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// formatMessage formats a record of the given message with the
+// wrapped logger, without queueing it, so that a ColoredLogger,
+// that wraps this logger, can color it.
+func (a *AsyncLogger) formatMessage(buf *bytes.Buffer, lvl LogLevel, msg string) error {
+	f, ok := a.queue.wrapped.(messageFormatter)
+	if !ok {
+		return errCannotFormat
+	}
+
+	rec := a.newRecord(lvl, msg)
+	defer freeRecord(rec)
+
+	return f.formatRecord(buf, rec)
+}
+
+// formatRecord formats the given record with the wrapped logger,
+// without queueing it.
+func (a *AsyncLogger) formatRecord(buf *bytes.Buffer, rec *Record) error {
+	f, ok := a.queue.wrapped.(messageFormatter)
+	if !ok {
+		return errCannotFormat
+	}
+
+	a.completeRecord(rec)
+	return f.formatRecord(buf, rec)
+}
+
+// Dropped returns the total number of records that were dropped,
+// because the queue was full.
+func (a *AsyncLogger) Dropped() uint64 {
+	return atomic.LoadUint64(&a.queue.dropped)
+}
+
+// Level returns the level of the wrapped logger, if it
+// has a Level method, and LevelVerbose otherwise.
+func (a *AsyncLogger) Level() LogLevel {
+	if l, ok := a.queue.wrapped.(interface{ Level() LogLevel }); ok {
+		return l.Level()
+	}
+	return LevelVerbose
+}
+
+// SetLevel delegates the given log level to the wrapped logger.
+func (a *AsyncLogger) SetLevel(lvl LogLevel) {
+	a.queue.wrapped.SetLevel(lvl)
+}
+
+// SetLevelString delegates the given log level to the wrapped logger.
+func (a *AsyncLogger) SetLevelString(level string) {
+	a.SetLevel(ToLogLevel(level))
+}
+
+// With returns a new AsyncLogger, that adds the given key/value pairs
+// to every record, in addition to the fields of this logger.
+// The new logger shares the queue and the wrapped logger with this logger.
+func (a *AsyncLogger) With(keyvals ...interface{}) Logger {
+	derived := &AsyncLogger{queue: a.queue}
+	a.derive(&derived.core, keyvals...)
+	derived.setHandler(a.queue)
+	return derived
+}
+
+// Out returns the writer of the wrapped logger.
+func (a *AsyncLogger) Out() io.Writer {
+	return a.queue.wrapped.Out()
+}
+
+// SetOut sets a new writer for the wrapped logger.
+func (a *AsyncLogger) SetOut(out io.Writer) {
+	a.queue.wrapped.SetOut(out)
+}
+
+// OnWriteError delegates to the wrapped loggers OnWriteError method,
+// if it implements WriteErrorHandler.
+// The function is called by the background goroutine.
+func (a *AsyncLogger) OnWriteError(fn func(error)) {
+	if h, ok := a.queue.wrapped.(WriteErrorHandler); ok {
+		h.OnWriteError(fn)
+	}
+}
+
+// SetFallback sets a fallback writer for the wrapped logger,
+// if it implements WriteErrorHandler.
+func (a *AsyncLogger) SetFallback(fallback io.Writer) {
+	if h, ok := a.queue.wrapped.(WriteErrorHandler); ok {
+		h.SetFallback(fallback)
+	}
+}
+
+// FailedWrites returns the number of failed writes of the wrapped
+// logger, if it implements WriteErrorHandler, and 0 otherwise.
+func (a *AsyncLogger) FailedWrites() uint64 {
+	if h, ok := a.queue.wrapped.(WriteErrorHandler); ok {
+		return h.FailedWrites()
+	}
+	return 0
+}
@@ -0,0 +1,256 @@
+package abc
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gateWriter is a writer that signals every write on started
+// and blocks until the gate is opened.
+type gateWriter struct {
+	started chan struct{}
+	gate    chan struct{}
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{
+		started: make(chan struct{}, 100),
+		gate:    make(chan struct{}),
+	}
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	w.started <- struct{}{}
+	<-w.gate
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gateWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+// newTestAsyncLogger returns an AsyncLogger around a SimpleLogger,
+// that writes to the given writer, and blocks until the first
+// message is being written, so that the queue is empty afterwards.
+func newTestAsyncLogger(w *gateWriter, opts AsyncOptions) *AsyncLogger {
+	logger := newAsyncLogger(newTestSimpleLogger(LevelVerbose, w), opts, &manualClock{})
+	logger.Info("first")
+	<-w.started
+	return logger
+}
+
+func TestAsyncLogger(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	logger := newAsyncLogger(newTestSimpleLogger(LevelInfo, buf), AsyncOptions{}, &manualClock{})
+
+	logger.Debug("not printed")
+	logger.Info("a")
+	logger.With("key", "value").Warnf("b%v", 1)
+	logger.Error("c")
+	logger.Close()
+	logger.Info("after close")
+
+	assert.Equal(""+
+		"0001-01-01 00:00:00.000 [INFO] - a\n"+
+		"0001-01-01 00:00:00.000 [WARN] - b1 key=value\n"+
+		"0001-01-01 00:00:00.000 [ERR ] - c\n"+
+		"0001-01-01 00:00:00.000 [INFO] - after close\n", buf.String())
+}
+
+func TestAsyncLogger_Flush(t *testing.T) {
+	assert := assert.New(t)
+
+	w := newGateWriter()
+	logger := newTestAsyncLogger(w, AsyncOptions{})
+	defer logger.Close()
+	logger.Info("second")
+
+	close(w.gate)
+	logger.Flush()
+	assert.Equal(""+
+		"0001-01-01 00:00:00.000 [INFO] - first\n"+
+		"0001-01-01 00:00:00.000 [INFO] - second\n", w.String())
+}
+
+func TestAsyncLogger_Overflow(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    AsyncOptions
+		want    []string
+		dropped uint64
+	}{
+		{
+			name:    "drop newest",
+			opts:    AsyncOptions{QueueSize: 2, Overflow: OverflowDropNewest},
+			want:    []string{"first", "a", "b"},
+			dropped: 3,
+		},
+		{
+			name:    "drop oldest",
+			opts:    AsyncOptions{QueueSize: 2, Overflow: OverflowDropOldest},
+			want:    []string{"first", "d", "e"},
+			dropped: 3,
+		},
+		{
+			name:    "drop below level",
+			opts:    AsyncOptions{QueueSize: 2, Overflow: OverflowDropBelowLevel, DropBelow: LevelFatal},
+			want:    []string{"first", "a", "b"},
+			dropped: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			w := newGateWriter()
+			logger := newTestAsyncLogger(w, tt.opts)
+			for _, msg := range []string{"a", "b", "c", "d", "e"} {
+				logger.Info(msg)
+			}
+			assert.Equal(tt.dropped, logger.Dropped())
+
+			close(w.gate)
+			logger.Close()
+
+			var got []string
+			for _, line := range strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n") {
+				got = append(got, strings.TrimPrefix(line, "0001-01-01 00:00:00.000 [INFO] - "))
+			}
+			assert.Equal(append(tt.want, "0001-01-01 00:00:00.000 [WARN] - dropped 3 log messages, because the queue was full"), got)
+		})
+	}
+}
+
+func TestAsyncLogger_DropOldestKeepsFlush(t *testing.T) {
+	assert := assert.New(t)
+
+	w := newGateWriter()
+	logger := newTestAsyncLogger(w, AsyncOptions{QueueSize: 1, Overflow: OverflowDropOldest})
+	defer logger.Close()
+
+	flushed := make(chan struct{})
+	go func() {
+		logger.Flush()
+		close(flushed)
+	}()
+	for len(logger.queue.items) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	logged := make(chan struct{})
+	go func() {
+		logger.Info("a") // takes the flush request from the full queue
+		close(logged)
+	}()
+	select {
+	case <-logged:
+	case <-time.After(time.Second):
+		t.Fatal("Info must not block while a flush request is queued")
+	}
+
+	close(w.gate)
+	select {
+	case <-flushed:
+	case <-time.After(time.Second):
+		t.Fatal("Flush was not answered")
+	}
+	assert.Equal(""+
+		"0001-01-01 00:00:00.000 [INFO] - first\n"+
+		"0001-01-01 00:00:00.000 [INFO] - a\n", w.String())
+	assert.Equal(uint64(0), logger.Dropped())
+}
+
+func TestAsyncLogger_DropReportIgnoresLevel(t *testing.T) {
+	assert := assert.New(t)
+
+	w := newGateWriter()
+	logger := newAsyncLogger(newTestSimpleLogger(LevelError, w), AsyncOptions{QueueSize: 1, Overflow: OverflowDropNewest}, &manualClock{})
+	logger.Error("first")
+	<-w.started
+	logger.Error("a")
+	logger.Error("dropped")
+
+	close(w.gate)
+	logger.Close()
+	assert.Equal(""+
+		"0001-01-01 00:00:00.000 [ERR ] - first\n"+
+		"0001-01-01 00:00:00.000 [ERR ] - a\n"+
+		"0001-01-01 00:00:00.000 [WARN] - dropped 1 log messages, because the queue was full\n", w.String())
+}
+
+func TestAsyncLogger_DropBelowLevelBlocks(t *testing.T) {
+	assert := assert.New(t)
+
+	w := newGateWriter()
+	logger := newTestAsyncLogger(w, AsyncOptions{QueueSize: 1, Overflow: OverflowDropBelowLevel, DropBelow: LevelWarn})
+	logger.Info("a")
+	logger.Info("dropped")
+
+	done := make(chan struct{})
+	go func() {
+		logger.Warn("blocks")
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Warn must block while the queue is full")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(w.gate)
+	<-done
+	logger.Close()
+	assert.Equal(""+
+		"0001-01-01 00:00:00.000 [INFO] - first\n"+
+		"0001-01-01 00:00:00.000 [INFO] - a\n"+
+		"0001-01-01 00:00:00.000 [WARN] - blocks\n"+
+		"0001-01-01 00:00:00.000 [WARN] - dropped 1 log messages, because the queue was full\n", w.String())
+}
+
+func TestAsyncLogger_DropReport(t *testing.T) {
+	assert := assert.New(t)
+
+	w := newGateWriter()
+	clk := &manualClock{ticks: make(chan time.Time)}
+	logger := newAsyncLogger(newTestSimpleLogger(LevelInfo, w), AsyncOptions{QueueSize: 1, Overflow: OverflowDropNewest}, clk)
+	defer logger.Close()
+
+	logger.Info("first")
+	<-w.started
+	logger.Info("a")
+	logger.Info("dropped")
+	close(w.gate)
+	<-w.started // writes a, so the queue is empty
+
+	clk.ticks <- time.Time{}
+	select {
+	case <-w.started: // writes the report
+	case <-time.After(time.Second):
+		t.Fatal("Dropped messages were not reported")
+	}
+
+	clk.ticks <- time.Time{} // nothing was dropped since the last report
+	logger.Info("b")
+	logger.Flush()
+
+	assert.Equal(""+
+		"0001-01-01 00:00:00.000 [INFO] - first\n"+
+		"0001-01-01 00:00:00.000 [INFO] - a\n"+
+		"0001-01-01 00:00:00.000 [WARN] - dropped 1 log messages, because the queue was full\n"+
+		"0001-01-01 00:00:00.000 [INFO] - b\n", w.String())
+}
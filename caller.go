@@ -0,0 +1,132 @@
+package abc
+
+import (
+	"bytes"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// CallerMode is the way in which a logger prints the
+// caller of a message.
+type CallerMode uint8
+
+// Available caller modes.
+const (
+	// CallerNone prints no caller.
+	CallerNone CallerMode = iota
+	// CallerFile prints the file name and the line of the caller,
+	// e.g. "main.go:16".
+	CallerFile
+	// CallerFunction prints the package and the function of the
+	// caller, e.g. "main.main" or "db.(*Conn).Query".
+	CallerFunction
+)
+
+// writeCaller writes a space and the caller of the given record
+// in the given mode to the given buffer.
+// Nothing is written if the record has no caller.
+func writeCaller(buf *bytes.Buffer, mode CallerMode, rec *Record) {
+	if mode == CallerNone || rec.PC == 0 {
+		return
+	}
+
+	frame := rec.Caller()
+	buf.WriteByte(' ')
+	switch mode {
+	case CallerFile:
+		buf.WriteString(filepath.Base(frame.File))
+		buf.WriteByte(':')
+		buf.WriteString(strconv.Itoa(frame.Line))
+	case CallerFunction:
+		buf.WriteString(frame.Function[strings.LastIndexByte(frame.Function, '/')+1:])
+	}
+}
+
+// sourceDir is the directory of the source files of this package,
+// including a trailing slash.
+var sourceDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return file[:strings.LastIndexByte(file, '/')+1]
+}()
+
+// isOwnFile returns true if the given file is a source file
+// of this package.
+// Test files don't count as source files, so that tests of this
+// package can test caller detection.
+func isOwnFile(file string) bool {
+	return strings.HasPrefix(file, sourceDir) &&
+		strings.IndexByte(file[len(sourceDir):], '/') < 0 &&
+		!strings.HasSuffix(file, "_test.go")
+}
+
+// callerPC returns the program counter of the first function
+// on the stack of the calling goroutine, that is not part of this
+// package, after skipping skip additional frames.
+// It returns 0 if there is no such function.
+//
+// Every frame is resolved the same way Record.Caller resolves
+// the PC, so inlined functions are handled correctly.
+func callerPC(skip int) uintptr {
+	var pcs [16]uintptr
+	// skip runtime.Callers and callerPC
+	offset := 2
+	for {
+		n := runtime.Callers(offset, pcs[:])
+		if n == 0 {
+			return 0
+		}
+
+		for _, pc := range pcs[:n] {
+			fn := runtime.FuncForPC(pc - 1)
+			if fn == nil {
+				continue
+			}
+			if file, _ := fn.FileLine(pc - 1); isOwnFile(file) {
+				continue
+			}
+			if skip == 0 {
+				return pc
+			}
+			skip--
+		}
+		offset += n
+	}
+}
+
+// callerSkipper is implemented by loggers that can skip
+// additional frames when they detect the caller.
+type callerSkipper interface {
+	addCallerSkip(n int)
+}
+
+// AddCallerSkip returns a new logger, that skips n additional stack
+// frames when it detects the caller of a log call.
+// Wrapper functions around a logger use it, so that the caller
+// of the wrapper is reported instead of the wrapper itself, e.g.
+//
+//	var logger = abc.AddCallerSkip(abc.NewJSONLogger(), 1)
+//
+//	func logRequest(r *http.Request) {
+//		logger.Infof("%v %v", r.Method, r.URL) // reports the caller of logRequest
+//	}
+//
+// Functions of this package are always skipped, so calls through
+// the package-level functions or wrappers like ColoredLogger
+// need no additional skip.
+// The new logger is created with With, so it starts with the
+// configuration of the given logger.
+// If the given logger is not a logger of this package,
+// the result of With, or the given logger, if it is no FieldLogger,
+// is returned unchanged.
+func AddCallerSkip(logger Logger, n int) Logger {
+	derived := logger
+	if fl, ok := logger.(FieldLogger); ok {
+		derived = fl.With()
+	}
+	if s, ok := derived.(callerSkipper); ok {
+		s.addCallerSkip(n)
+	}
+	return derived
+}
@@ -0,0 +1,114 @@
+package abc
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// nextLine returns the line after the line of its call.
+func nextLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line + 1
+}
+
+// logThroughWrapper is a wrapper function around a logger.
+func logThroughWrapper(logger Logger, msg string) {
+	logger.Info(msg)
+}
+
+func TestCallerDetection(t *testing.T) {
+	var records []Record
+	handler := HandlerFunc(func(rec *Record) error {
+		records = append(records, *rec)
+		return nil
+	})
+	newLogger := func() Logger {
+		logger := NewHandlerLogger(handler).(*HandlerLogger)
+		logger.SetClock(&mockClock{})
+		return logger
+	}
+
+	tests := []struct {
+		name string
+		log  func() int
+	}{
+		{"method", func() int {
+			line := nextLine()
+			newLogger().Info("message")
+			return line
+		}},
+		{"formatting method", func() int {
+			line := nextLine()
+			newLogger().Warnf("message %v", 1)
+			return line
+		}},
+		{"Print", func() int {
+			line := nextLine()
+			newLogger().Print(LevelInfo, "message")
+			return line
+		}},
+		{"Printf", func() int {
+			line := nextLine()
+			newLogger().Printf(LevelInfo, "message %v", 1)
+			return line
+		}},
+		{"derived logger", func() int {
+			line := nextLine()
+			newLogger().(FieldLogger).With("key", "value").Error("message")
+			return line
+		}},
+		{"package-level function", func() int {
+			defer SetRoot(Root())
+			SetRoot(newLogger())
+			line := nextLine()
+			Infof("message %v", 1)
+			return line
+		}},
+		{"wrapper with skip", func() int {
+			logger := AddCallerSkip(newLogger(), 1)
+			line := nextLine()
+			logThroughWrapper(logger, "message")
+			return line
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			records = nil
+			line := tt.log()
+			if assert.Len(records, 1) {
+				frame := records[0].Caller()
+				assert.Equal("caller_test.go", filepath.Base(frame.File))
+				assert.Equal(line, frame.Line)
+			}
+		})
+	}
+}
+
+func TestCallerDetection_Wrappers(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	colored := NewColoredLogger(newTestJSONLogger("", LevelInfo, buf))
+	line := nextLine()
+	colored.Info("message")
+	assert.Contains(buf.String(), fmt.Sprintf(`"caller":"%v/caller_test.go:%v"`, filepath.Base(sourceDir), line))
+
+	buf.Reset()
+	line = nextLine()
+	logThroughWrapper(AddCallerSkip(colored, 1), "message")
+	assert.Contains(buf.String(), fmt.Sprintf(`"caller":"%v/caller_test.go:%v"`, filepath.Base(sourceDir), line))
+
+	buf.Reset()
+	async := newAsyncLogger(newTestJSONLogger("", LevelInfo, buf), AsyncOptions{}, &manualClock{})
+	line = nextLine()
+	logThroughWrapper(AddCallerSkip(async, 1), "message")
+	async.Close()
+	assert.Contains(buf.String(), fmt.Sprintf(`"caller":"%v/caller_test.go:%v"`, filepath.Base(sourceDir), line))
+}
@@ -1,8 +1,40 @@
 package abc
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 type mockClock struct{}
 
 func (mockClock) Now() time.Time                         { return time.Time{} }
 func (mockClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// manualClock is a clock that only advances when told so.
+// If ticks is not nil, After returns ticks.
+type manualClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	ticks chan time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *manualClock) After(d time.Duration) <-chan time.Time {
+	if c.ticks != nil {
+		return c.ticks
+	}
+	return time.After(d)
+}
+
+func (c *manualClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = t
+}
@@ -0,0 +1,165 @@
+package abc
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// colorKind is the kind of a TermColor.
+type colorKind uint8
+
+const (
+	colorDefault colorKind = iota
+	colorBasic
+	color256
+	colorTrue
+)
+
+// TermColor is a foreground or background color of a terminal.
+// The zero value is the default color of the terminal.
+type TermColor struct {
+	kind    colorKind
+	r, g, b uint8
+}
+
+// BasicColor returns one of the 16 basic colors, that every
+// terminal supports.
+//
+//	0 black   1 red       2 green   3 yellow
+//	4 blue    5 magenta   6 cyan    7 white
+//
+// 8 to 15 are the bright variants of 0 to 7.
+// Greater values are used as Color256.
+func BasicColor(n uint8) TermColor {
+	if n > 15 {
+		return Color256(n)
+	}
+	return TermColor{kind: colorBasic, r: n}
+}
+
+// Color256 returns a color of the 256-color palette.
+func Color256(n uint8) TermColor {
+	return TermColor{kind: color256, r: n}
+}
+
+// TrueColor returns a 24-bit RGB color.
+func TrueColor(r, g, b uint8) TermColor {
+	return TermColor{kind: colorTrue, r: r, g: g, b: b}
+}
+
+// appendParams appends the SGR parameters of the color to the given
+// parameters. base is 30 for foreground and 40 for background colors.
+func (c TermColor) appendParams(params []string, base int) []string {
+	switch c.kind {
+	case colorBasic:
+		if c.r < 8 {
+			return append(params, strconv.Itoa(base+int(c.r)))
+		}
+		return append(params, strconv.Itoa(base+60+int(c.r)-8))
+	case color256:
+		return append(params, strconv.Itoa(base+8), "5", strconv.Itoa(int(c.r)))
+	case colorTrue:
+		return append(params, strconv.Itoa(base+8), "2", strconv.Itoa(int(c.r)), strconv.Itoa(int(c.g)), strconv.Itoa(int(c.b)))
+	}
+	return params
+}
+
+// ColorStyle is the style, with which messages of a level are printed.
+// The zero value prints with the default style of the terminal.
+type ColorStyle struct {
+	Foreground TermColor
+	Background TermColor
+	Bold       bool
+}
+
+// code returns the ANSI escape code of the style.
+func (s ColorStyle) code() color {
+	params := s.Foreground.appendParams(nil, 30)
+	params = s.Background.appendParams(params, 40)
+	if s.Bold {
+		params = append(params, "1")
+	}
+	if len(params) == 0 {
+		return ColorNone
+	}
+	return color("\u001b[" + strings.Join(params, ";") + "m")
+}
+
+// ColorTheme maps log levels to the style, with which their messages
+// are printed. Levels that are not in the theme are printed with the
+// default style of the terminal.
+type ColorTheme map[LogLevel]ColorStyle
+
+// DefaultColorTheme returns the theme, that a ColoredLogger uses
+// if no other theme was set.
+func DefaultColorTheme() ColorTheme {
+	return ColorTheme{
+		LevelVerbose: {Foreground: BasicColor(0), Bold: true},
+		LevelDebug:   {},
+		LevelInfo:    {Foreground: BasicColor(2), Bold: true},
+		LevelWarn:    {Foreground: BasicColor(3)},
+		LevelError:   {Foreground: BasicColor(1)},
+		LevelFatal:   {Foreground: BasicColor(1)},
+	}
+}
+
+// defaultTheme is the compiled DefaultColorTheme.
+var defaultTheme = compileTheme(DefaultColorTheme())
+
+func (t ColorTheme) clone() ColorTheme {
+	cloned := make(ColorTheme, len(t))
+	for lvl, style := range t {
+		cloned[lvl] = style
+	}
+	return cloned
+}
+
+// codes returns the escape codes of all levels up to LevelFatal,
+// indexed by the level.
+func (t ColorTheme) codes() []color {
+	codes := make([]color, LevelFatal+1)
+	for lvl := range codes {
+		codes[lvl] = t[LogLevel(lvl)].code()
+	}
+	return codes
+}
+
+// compiledTheme is an immutable theme with the escape codes of its levels.
+type compiledTheme struct {
+	theme ColorTheme
+	codes []color
+}
+
+// compileTheme compiles a copy of the given theme.
+func compileTheme(theme ColorTheme) *compiledTheme {
+	theme = theme.clone()
+	return &compiledTheme{
+		theme: theme,
+		codes: theme.codes(),
+	}
+}
+
+// levelCode returns the escape code of the given level.
+// Levels after LevelFatal have the code of LevelDebug.
+func levelCode(codes []color, lvl LogLevel) color {
+	if int(lvl) < len(codes) {
+		return codes[lvl]
+	}
+	return codes[LevelDebug]
+}
+
+// startColor writes the escape code of the given level,
+// if codes is not nil.
+func startColor(buf *bytes.Buffer, codes []color, lvl LogLevel) {
+	if codes != nil {
+		buf.Write(levelCode(codes, lvl))
+	}
+}
+
+// endColor writes the color reset, if codes is not nil.
+func endColor(buf *bytes.Buffer, codes []color) {
+	if codes != nil {
+		buf.Write(ColorReset)
+	}
+}
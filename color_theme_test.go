@@ -0,0 +1,42 @@
+package abc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColorStyle_Code(t *testing.T) {
+	tests := []struct {
+		name     string
+		style    ColorStyle
+		expected string
+	}{
+		{"default", ColorStyle{}, "\u001b[0m"},
+		{"basic", ColorStyle{Foreground: BasicColor(1)}, "\u001b[31m"},
+		{"bright", ColorStyle{Foreground: BasicColor(9)}, "\u001b[91m"},
+		{"bold", ColorStyle{Bold: true}, "\u001b[1m"},
+		{"background", ColorStyle{Foreground: BasicColor(7), Background: BasicColor(4), Bold: true}, "\u001b[37;44;1m"},
+		{"bright background", ColorStyle{Background: BasicColor(12)}, "\u001b[104m"},
+		{"256", ColorStyle{Foreground: Color256(208), Background: Color256(17)}, "\u001b[38;5;208;48;5;17m"},
+		{"basic above 15", ColorStyle{Foreground: BasicColor(208)}, "\u001b[38;5;208m"},
+		{"truecolor", ColorStyle{Foreground: TrueColor(255, 128, 0), Background: TrueColor(0, 0, 32)}, "\u001b[38;2;255;128;0;48;2;0;0;32m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, string(tt.style.code()))
+		})
+	}
+}
+
+func TestDefaultColorTheme(t *testing.T) {
+	assert := assert.New(t)
+
+	codes := DefaultColorTheme().codes()
+	assert.Equal(ColorGray, codes[LevelVerbose])
+	assert.Equal(ColorNone, codes[LevelDebug])
+	assert.Equal(ColorGreen, codes[LevelInfo])
+	assert.Equal(ColorYellow, codes[LevelWarn])
+	assert.Equal(ColorRed, codes[LevelError])
+	assert.Equal(ColorRed, codes[LevelFatal])
+}
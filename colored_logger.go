@@ -1,8 +1,13 @@
 package abc
 
 import (
+	"bytes"
+	"fmt"
 	"io"
+	"os"
+	"reflect"
 	"sync"
+	"sync/atomic"
 )
 
 type color []byte
@@ -17,86 +22,297 @@ var (
 	ColorYellow = color("\u001b[33m")
 )
 
+// ColorMode controls, whether a ColoredLogger writes color codes.
+type ColorMode uint8
+
+// Available color modes.
+const (
+	// ColorModeAuto writes color codes if the output writer is a
+	// terminal. The environment variables NO_COLOR, FORCE_COLOR and
+	// TERM are respected, see ColoredLogger.
+	ColorModeAuto ColorMode = iota
+	// ColorModeAlways always writes color codes.
+	ColorModeAlways
+	// ColorModeNever never writes color codes.
+	ColorModeNever
+)
+
 // ColoredLogger is a wrapper for any WriterLogger.
 // Depending on the level that should be printed, this wrapper
-// will prepend an ANSI-color code to the wrapped loggers
-// output writer and will then call the respective output method.
-// Please notice that this is not add a decorator for
-// a given logger, but a wrapper, which must be used
-// for colors to show up.
+// surrounds the output of the wrapped logger with an ANSI-color code
+// and the color code reset.
+// To color only the level instead of whole lines, see
+// SimpleLogger.SetColorMode, NamedLogger.SetColorMode and the
+// color function of the CustomPatternLogger.
 //
-// The color codes are written to the wrapped loggers output
-// writer.
-// An output call will trigger three writes on the wrapped loggers
-// output writer.
+// The colors of the levels are taken from a ColorTheme, which
+// can be changed with SetTheme.
+// By default, color codes are only written if the output writer
+// is a terminal, so that the same application writes clean log
+// files and colorful output to a terminal. This can be changed
+// with SetColorMode. In ColorModeAuto, the environment is checked
+// in the following order, whenever the mode or the output writer
+// is set:
 //
-// 1. color code
+//	FORCE_COLOR  if set and not empty, colors are written, unless it is "0" or "false"
+//	NO_COLOR     if set and not empty, no colors are written
+//	TERM         if "dumb", no colors are written
 //
-// 2. output from wrapped logger
+// The ColoredLogger has its own output writer, which initially is
+// the writer of the wrapped logger. The wrapped logger is not
+// modified, so it can still be used on its own, and wrapping a
+// logger more than once is possible.
+// Messages are formatted by the wrapped logger, and the color code of
+// the level, the message and the reset are written with a single write
+// to the output writer of the ColoredLogger, so colored messages don't
+// interleave with other messages that are written to the same writer.
+// The level checks are passed to the wrapped logger as well, so changes
+// of the level or the format of the wrapped logger take effect
+// immediately.
+// Loggers of other packages write their messages themselves, so for
+// them, the color code and the reset are written to the writer of the
+// wrapped logger with separate writes around the call of the wrapped
+// logger, and the output writer of the ColoredLogger is only used to
+// decide whether colors are written.
 //
-// 3. color code reset
-//
-// The second call will not originate from the wrapper,
-// as, after the color code was written, Print (or respectively Printf)
-// will be called on the wrapped logger.
+// Messages are written synchronously, even if the wrapped logger is
+// an AsyncLogger. To write colored messages in the background, wrap
+// the ColoredLogger in an AsyncLogger instead.
 type ColoredLogger struct {
-	wrappedLock sync.Mutex
-	wrapped     WriterLogger
+	writeErrors
+
+	wrapped WriterLogger
+	colors  *colorState
+	// mu keeps the color codes and the messages of loggers of other
+	// packages together. It is shared with all derived loggers.
+	mu *sync.Mutex
+}
+
+// colorState is the state, with which colored output is written.
+type colorState struct {
+	out atomic.Pointer[io.Writer]
+	// theme is the current theme together with its escape codes.
+	theme atomic.Pointer[compiledTheme]
+	// enabled is whether color codes are written, which is
+	// decided from mode and out.
+	enabled atomic.Bool
+
+	// modeMux guards mode and the updates of enabled.
+	modeMux sync.Mutex
+	mode    ColorMode
+}
+
+// setOut sets the writer and decides again, whether colors are written.
+func (c *colorState) setOut(out io.Writer) {
+	c.modeMux.Lock()
+	defer c.modeMux.Unlock()
+
+	c.out.Store(&out)
+	c.enabled.Store(colorsEnabled(c.mode, out))
+}
+
+// setMode sets the mode and decides again, whether colors are written.
+func (c *colorState) setMode(mode ColorMode) {
+	c.modeMux.Lock()
+	defer c.modeMux.Unlock()
+
+	c.mode = mode
+	c.enabled.Store(colorsEnabled(mode, *c.out.Load()))
+}
+
+func (c *colorState) getMode() ColorMode {
+	c.modeMux.Lock()
+	defer c.modeMux.Unlock()
+
+	return c.mode
+}
+
+// colorsEnabled decides whether color codes are written to the
+// given writer in the given mode.
+func colorsEnabled(mode ColorMode, out io.Writer) bool {
+	switch mode {
+	case ColorModeAlways:
+		return true
+	case ColorModeNever:
+		return false
+	}
+
+	if force := os.Getenv("FORCE_COLOR"); force != "" {
+		return force != "0" && force != "false"
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(out)
+}
+
+// isTerminal returns whether the given writer is a terminal, which
+// is assumed for files that are character devices.
+func isTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
 
-func (s *ColoredLogger) printWithColor(clr color, lvl LogLevel, v ...interface{}) {
-	s.wrappedLock.Lock()
-	defer s.wrappedLock.Unlock()
+// newColoredLogger wraps the given logger in a ColoredLogger with
+// the given theme and mode, that writes to the given writer.
+func newColoredLogger(wrapped WriterLogger, out io.Writer, theme *compiledTheme, mode ColorMode) *ColoredLogger {
+	colors := &colorState{mode: mode}
+	colors.theme.Store(theme)
+	colors.setOut(out)
 
-	if s.IsLevelEnabled(lvl) {
-		s.wrapped.Out().Write(clr)
-		s.wrapped.Print(lvl, v...)
-		s.wrapped.Out().Write(ColorReset)
+	return &ColoredLogger{
+		writeErrors: writeErrors{failed: new(uint64)},
+		wrapped:     wrapped,
+		colors:      colors,
+		mu:          &sync.Mutex{},
 	}
 }
 
-func (s *ColoredLogger) printfWithColor(clr color, lvl LogLevel, format string, v ...interface{}) {
-	s.wrappedLock.Lock()
-	defer s.wrappedLock.Unlock()
+// Log formats the given record with the wrapped logger and writes it
+// in the color of its level to the output writer.
+func (s *ColoredLogger) Log(rec *Record) error {
+	if !s.wrapped.IsLevelEnabled(rec.Level) {
+		return nil
+	}
 
-	if s.IsLevelEnabled(lvl) {
-		s.wrapped.Out().Write(clr)
-		s.wrapped.Printf(lvl, format, v...)
-		s.wrapped.Out().Write(ColorReset)
+	err := s.write(rec.Level, func(buf *bytes.Buffer, f messageFormatter) error {
+		return f.formatRecord(buf, rec)
+	})
+	if err == errCannotFormat {
+		return s.writeAround(rec.Level, func() error {
+			return logRecord(s.wrapped, rec)
+		})
 	}
+	return err
 }
 
-// Print delegates the values with the given log level to the wrapped
-// logger while writing ansi color codes to the wrapped loggers output writer.
+// Print formats the values with the given log level with the wrapped
+// logger and writes them in the color of the level to the output writer.
+// The values are only formatted if the level is enabled,
+// so that calls with a disabled level don't allocate.
 func (s *ColoredLogger) Print(lvl LogLevel, v ...interface{}) {
-	color := s.getColorForLevel(lvl)
-	s.printWithColor(color, lvl, v...)
+	if s.wrapped.IsLevelEnabled(lvl) {
+		s.log(lvl, fmt.Sprint(v...))
+	}
 }
 
-// Printf delegates the format string and values with the given log level to the wrapped
-// logger while writing ansi color codes to the wrapped loggers output writer.
+// Printf formats the format string and values with the given log level
+// with the wrapped logger and writes them in the color of the level
+// to the output writer.
 func (s *ColoredLogger) Printf(lvl LogLevel, format string, v ...interface{}) {
-	color := s.getColorForLevel(lvl)
-	s.printfWithColor(color, lvl, format, v...)
+	if s.wrapped.IsLevelEnabled(lvl) {
+		s.log(lvl, fmt.Sprintf(format, v...))
+	}
+}
+
+// log formats the given message with the wrapped logger and writes
+// it in the color of the given level.
+func (s *ColoredLogger) log(lvl LogLevel, msg string) {
+	err := s.write(lvl, func(buf *bytes.Buffer, f messageFormatter) error {
+		return f.formatMessage(buf, lvl, msg)
+	})
+	if err == errCannotFormat {
+		_ = s.writeAround(lvl, func() error {
+			s.wrapped.Print(lvl, msg)
+			return nil
+		})
+	}
+}
+
+// write formats a message with the given function into a buffer,
+// surrounds it with the color code of the given level and the reset,
+// and writes it with a single write to the output writer.
+// If the message can't be written, it is written without color codes
+// to the fallback writer.
+// If the wrapped logger can't format its messages, errCannotFormat
+// is returned and nothing is written.
+func (s *ColoredLogger) write(lvl LogLevel, format func(*bytes.Buffer, messageFormatter) error) error {
+	f, ok := s.wrapped.(messageFormatter)
+	if !ok {
+		return errCannotFormat
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	enabled := s.colors.enabled.Load()
+	if enabled {
+		buf.Write(levelCode(s.colors.theme.Load().codes, lvl))
+	}
+	start := buf.Len()
+	if err := format(buf, f); err != nil {
+		return err
+	}
+	end := buf.Len()
+	if enabled {
+		buf.Write(ColorReset)
+	}
+
+	if _, err := s.Out().Write(buf.Bytes()); err != nil {
+		s.writeFailed(err, buf.Bytes()[start:end])
+		return err
+	}
+	return nil
 }
 
-func (s *ColoredLogger) getColorForLevel(lvl LogLevel) color {
-	switch lvl {
-	case LevelVerbose:
-		return ColorGray
-	case LevelDebug:
-		return ColorNone
-	case LevelInfo:
-		return ColorGreen
-	case LevelWarn:
-		return ColorYellow
-	case LevelError:
-		return ColorRed
-	case LevelFatal:
-		return ColorRed
-	default:
-		return ColorNone
+// writeAround writes the color code of the given level and the reset
+// to the writer of the wrapped logger, before and after the given
+// function is called.
+// This is used for loggers of other packages, that write their messages
+// themselves.
+func (s *ColoredLogger) writeAround(lvl LogLevel, log func() error) error {
+	if !s.colors.enabled.Load() {
+		return log()
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := s.wrapped.Out()
+	_, _ = out.Write(levelCode(s.colors.theme.Load().codes, lvl))
+	defer func() {
+		_, _ = out.Write(ColorReset)
+	}()
+	return log()
+}
+
+// formatMessage formats the given message with the wrapped logger,
+// surrounded by the color code of the level and the reset, so that
+// a ColoredLogger can be wrapped in another ColoredLogger.
+func (s *ColoredLogger) formatMessage(buf *bytes.Buffer, lvl LogLevel, msg string) error {
+	return s.formatColored(buf, lvl, func(f messageFormatter) error {
+		return f.formatMessage(buf, lvl, msg)
+	})
+}
+
+// formatRecord formats the given record like formatMessage.
+func (s *ColoredLogger) formatRecord(buf *bytes.Buffer, rec *Record) error {
+	return s.formatColored(buf, rec.Level, func(f messageFormatter) error {
+		return f.formatRecord(buf, rec)
+	})
+}
+
+// formatColored formats a message with the given function, surrounded
+// by the color code of the given level and the reset.
+func (s *ColoredLogger) formatColored(buf *bytes.Buffer, lvl LogLevel, format func(messageFormatter) error) error {
+	f, ok := s.wrapped.(messageFormatter)
+	if !ok {
+		return errCannotFormat
+	}
+	if !s.colors.enabled.Load() {
+		return format(f)
+	}
+
+	buf.Write(levelCode(s.colors.theme.Load().codes, lvl))
+	if err := format(f); err != nil {
+		return err
+	}
+	buf.Write(ColorReset)
+	return nil
 }
 
 // Verbose delegates the given values to the wrapped logger
@@ -189,12 +405,120 @@ func (s *ColoredLogger) IsLevelEnabled(lvl LogLevel) bool {
 	return s.wrapped.IsLevelEnabled(lvl)
 }
 
-// Out returns the writer of the wrapped logger.
+// With returns a new ColoredLogger, wrapping the logger that
+// With of the wrapped logger returns.
+// The fields are rendered by the wrapped logger.
+// The derived logger has the writer, theme, color mode and write error
+// handling of this logger, and counts its failed writes together with
+// this logger.
+// If the derived logger is not a WriterLogger, it is returned
+// without wrapper, and if the wrapped logger is no FieldLogger,
+// this logger is returned.
+func (s *ColoredLogger) With(keyvals ...interface{}) Logger {
+	fl, ok := s.wrapped.(FieldLogger)
+	if !ok {
+		return s
+	}
+	derived := fl.With(keyvals...)
+	wrapped, ok := derived.(WriterLogger)
+	if !ok {
+		return derived
+	}
+
+	logger := newColoredLogger(wrapped, s.Out(), s.colors.theme.Load(), s.ColorMode())
+	s.deriveWriteErrors(&logger.writeErrors)
+	logger.mu = s.mu
+	return logger
+}
+
+// Theme returns a copy of the color theme of the logger.
+func (s *ColoredLogger) Theme() ColorTheme {
+	return s.colors.theme.Load().theme.clone()
+}
+
+// SetTheme sets the color theme of the logger.
+// The theme is copied, so later changes of the given theme
+// have no effect.
+func (s *ColoredLogger) SetTheme(theme ColorTheme) {
+	s.colors.theme.Store(compileTheme(theme))
+}
+
+// ColorMode returns the color mode of the logger.
+func (s *ColoredLogger) ColorMode() ColorMode {
+	return s.colors.getMode()
+}
+
+// SetColorMode sets the color mode of the logger, which
+// controls whether color codes are written.
+func (s *ColoredLogger) SetColorMode(mode ColorMode) {
+	s.colors.setMode(mode)
+}
+
+// ColorsEnabled returns whether color codes are currently written.
+func (s *ColoredLogger) ColorsEnabled() bool {
+	return s.colors.enabled.Load()
+}
+
+// Out returns the output writer of the logger.
 func (s *ColoredLogger) Out() io.Writer {
-	return s.wrapped.Out()
+	return *s.colors.out.Load()
 }
 
-// SetOut sets a new writer for the wrapped logger.
+// SetOut sets a new output writer for the logger.
+// The writer of the wrapped logger is not changed.
+// In ColorModeAuto, it is decided again whether colors are written.
 func (s *ColoredLogger) SetOut(out io.Writer) {
-	s.wrapped.SetOut(out)
+	s.colors.setOut(out)
+}
+
+// Sync delegates to the wrapped loggers Sync method,
+// if it implements Syncer, and syncs the output writer,
+// if it is not the writer of the wrapped logger.
+func (s *ColoredLogger) Sync() error {
+	var err error
+	if sy, ok := s.wrapped.(Syncer); ok {
+		err = sy.Sync()
+	}
+	if out := s.Out(); !sameWriter(out, s.wrapped.Out()) {
+		if syncErr := syncWriter(out); err == nil {
+			err = syncErr
+		}
+	}
+	return err
+}
+
+// Close delegates to the wrapped loggers Close method,
+// if it implements io.Closer, and closes the output writer,
+// if it is not the writer of the wrapped logger.
+func (s *ColoredLogger) Close() error {
+	out := s.Out()
+	own := !sameWriter(out, s.wrapped.Out())
+
+	var err error
+	if c, ok := s.wrapped.(io.Closer); ok {
+		err = c.Close()
+	}
+	if own {
+		if closeErr := closeWriter(out); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// sameWriter returns whether the given writers are the same writer.
+// Writers, that can't be compared, are never the same.
+func sameWriter(a, b io.Writer) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	t := reflect.TypeOf(a)
+	return t == reflect.TypeOf(b) && t.Comparable() && a == b
+}
+
+// addCallerSkip delegates to the wrapped logger.
+func (s *ColoredLogger) addCallerSkip(n int) {
+	if skipper, ok := s.wrapped.(callerSkipper); ok {
+		skipper.addCallerSkip(n)
+	}
 }
@@ -5,13 +5,7 @@ import (
 )
 
 func BenchmarkColoredLogger_SimpleLogger_Printf(b *testing.B) {
-	logger := &ColoredLogger{
-		wrapped: &SimpleLogger{
-			clk: &mockClock{},
-			lvl: LevelVerbose,
-			out: &MockWriter{},
-		},
-	}
+	logger := newTestColoredLogger(newTestSimpleLogger(LevelVerbose, &MockWriter{}))
 	b.ReportAllocs()
 	b.ResetTimer()
 	b.RunParallel(func(pb *testing.PB) {
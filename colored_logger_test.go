@@ -2,6 +2,10 @@ package abc
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,15 +16,9 @@ func TestColoredLogger_Printf(t *testing.T) {
 
 	buf := &bytes.Buffer{}
 
-	l := &SimpleLogger{
-		clk: &mockClock{},
-		lvl: LevelDebug,
-		out: buf,
-	}
+	l := newTestSimpleLogger(LevelDebug, buf)
 
-	logger := &ColoredLogger{
-		wrapped: l,
-	}
+	logger := newTestColoredLogger(l)
 
 	type args struct {
 		lvl    LogLevel
@@ -109,15 +107,9 @@ func TestColoredLogger_Print(t *testing.T) {
 
 	buf := &bytes.Buffer{}
 
-	l := &SimpleLogger{
-		clk: &mockClock{},
-		lvl: LevelDebug,
-		out: buf,
-	}
+	l := newTestSimpleLogger(LevelDebug, buf)
 
-	logger := &ColoredLogger{
-		wrapped: l,
-	}
+	logger := newTestColoredLogger(l)
 
 	type args struct {
 		lvl LogLevel
@@ -215,15 +207,9 @@ func TestColoredLogger_All_Outputs(t *testing.T) {
 
 	buf := &bytes.Buffer{}
 
-	l := &SimpleLogger{
-		clk: &mockClock{},
-		lvl: LevelVerbose,
-		out: buf,
-	}
+	l := newTestSimpleLogger(LevelVerbose, buf)
 
-	logger := &ColoredLogger{
-		wrapped: l,
-	}
+	logger := newTestColoredLogger(l)
 
 	check := func() {
 		defer func() {
@@ -272,15 +258,9 @@ func TestColoredLogger_SetOut(t *testing.T) {
 	buf1 := &bytes.Buffer{}
 	buf2 := &bytes.Buffer{}
 
-	l := &SimpleLogger{
-		clk: &mockClock{},
-		lvl: LevelVerbose,
-		out: buf1,
-	}
+	l := newTestSimpleLogger(LevelVerbose, buf1)
 
-	logger := &ColoredLogger{
-		wrapped: l,
-	}
+	logger := newTestColoredLogger(l)
 
 	logger.Info("foo")
 	assert.Equal(string(ColorGreen)+"0001-01-01 00:00:00.000 [INFO] - foo\n"+string(ColorReset), buf1.String(), "buf1 did receive wrong output.")
@@ -312,15 +292,9 @@ func TestColoredLogger_SetLevel(t *testing.T) {
 
 	buf := &bytes.Buffer{}
 
-	l := &SimpleLogger{
-		clk: &mockClock{},
-		lvl: LevelVerbose,
-		out: buf,
-	}
+	l := newTestSimpleLogger(LevelVerbose, buf)
 
-	logger := &ColoredLogger{
-		wrapped: l,
-	}
+	logger := newTestColoredLogger(l)
 
 	logger.Verbose("foo")
 	assert.Equal(string(ColorGray)+"0001-01-01 00:00:00.000 [DEBG] - foo\n"+string(ColorNone), buf.String(), "buf did receive wrong output.")
@@ -331,3 +305,279 @@ func TestColoredLogger_SetLevel(t *testing.T) {
 	logger.Verbose("foo")
 	assert.Equal("", buf.String(), "buf did receive output.")
 }
+
+func TestColoredLogger_With(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	l := newTestSimpleLogger(LevelVerbose, buf)
+
+	logger := newTestColoredLogger(l)
+
+	derived := logger.With("request", 17)
+	_, ok := derived.(*ColoredLogger)
+	assert.True(ok, "Logger was expected to be of type *ColoredLogger, but was not.")
+
+	derived.Info("abc")
+	assert.Equal(string(ColorGreen)+"0001-01-01 00:00:00.000 [INFO] - abc request=17\n"+string(ColorReset), buf.String(), "buf did receive wrong output.")
+}
+
+func TestColoredLogger_WrappedChanges(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	wrapped := newTestCustomPatternLogger("[{{.Level}}] {{.Message}}\n", LevelInfo, buf)
+	logger := newTestColoredLogger(wrapped)
+
+	wrapped.SetLevel(LevelError)
+	logger.Info("abc")
+	assert.Equal("", buf.String(), "The level of the wrapped logger must be used")
+	assert.False(logger.IsLevelEnabled(LevelInfo))
+
+	assert.NoError(wrapped.SetPattern("{{.Level | lower}}: {{.Message}}\n"))
+	logger.Error("abc")
+	assert.Equal(string(ColorRed)+"err : abc\n"+string(ColorReset), buf.String(), "The pattern of the wrapped logger must be used")
+}
+
+func TestColoredLogger_Hierarchy(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	parent := newTestNamedLogger("app", LevelInfo, buf)
+	logger := newTestColoredLogger(parent.Child("db"))
+
+	logger.Debug("abc")
+	assert.Equal("", buf.String())
+
+	parent.SetLevel(LevelDebug)
+	logger.Debug("abc")
+	assert.Equal(string(ColorNone)+"0001-01-01 00:00:00.000 <app.db> [DEBG] - abc\n"+string(ColorReset), buf.String(), "The level of the parent must be inherited")
+}
+
+func TestColoredLogger_Async(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	async := newAsyncLogger(newTestSimpleLogger(LevelInfo, buf), AsyncOptions{}, &manualClock{})
+	logger := newTestColoredLogger(async)
+	derived := logger.With("request", 17)
+
+	logger.Info("abc")
+	derived.Warn("def")
+	logger.Error("ghi")
+	assert.NoError(logger.Close())
+
+	assert.Equal(string(ColorGreen)+"0001-01-01 00:00:00.000 [INFO] - abc\n"+string(ColorReset)+
+		string(ColorYellow)+"0001-01-01 00:00:00.000 [WARN] - def request=17\n"+string(ColorReset)+
+		string(ColorRed)+"0001-01-01 00:00:00.000 [ERR ] - ghi\n"+string(ColorReset), buf.String())
+}
+
+// plainWithLogger is a WriterLogger, whose With returns a Logger,
+// that is no WriterLogger.
+type plainWithLogger struct {
+	*SimpleLogger
+}
+
+func (l plainWithLogger) With(keyvals ...interface{}) Logger {
+	return struct{ Logger }{l.SimpleLogger.With(keyvals...)}
+}
+
+func TestColoredLogger_WithPlainLogger(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	logger := newTestColoredLogger(plainWithLogger{newTestSimpleLogger(LevelInfo, buf)})
+
+	var derived Logger
+	assert.NotPanics(func() {
+		derived = logger.With("request", 17)
+	})
+	_, ok := derived.(*ColoredLogger)
+	assert.False(ok, "A Logger, that is no WriterLogger, can't be wrapped")
+}
+
+func TestColoredLogger_WrappedUnmodified(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	wrapped := newTestSimpleLogger(LevelInfo, buf)
+	logger := newTestColoredLogger(wrapped)
+	twice := newTestColoredLogger(wrapped)
+
+	assert.Equal(buf, wrapped.Out(), "The writer of the wrapped logger must not be replaced")
+
+	wrapped.Info("abc")
+	assert.Equal("0001-01-01 00:00:00.000 [INFO] - abc\n", buf.String(), "The wrapped logger must not write colors")
+
+	buf.Reset()
+	logger.Info("abc")
+	twice.Warn("def")
+	assert.Equal(string(ColorGreen)+"0001-01-01 00:00:00.000 [INFO] - abc\n"+string(ColorReset)+
+		string(ColorYellow)+"0001-01-01 00:00:00.000 [WARN] - def\n"+string(ColorReset), buf.String(), "Wrapping a logger twice must not color messages twice")
+
+	out := &bytes.Buffer{}
+	logger.SetOut(out)
+	assert.Equal(buf, wrapped.Out(), "The writer of the wrapped logger must not be changed")
+}
+
+func TestColoredLogger_OtherLogger(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	// other is a WriterLogger, whose messages can only be written by itself
+	other := struct{ WriterLogger }{newTestSimpleLogger(LevelInfo, buf)}
+	logger := newTestColoredLogger(other)
+
+	logger.Error("abc")
+	assert.NoError(logger.Log(&Record{Level: LevelWarn, Message: "def"}))
+	logger.Debug("ghi")
+	assert.Equal(string(ColorRed)+"0001-01-01 00:00:00.000 [ERR ] - abc\n"+string(ColorReset)+
+		string(ColorYellow)+"0001-01-01 00:00:00.000 [WARN] - def\n"+string(ColorReset), buf.String(), "Messages of other loggers must be colored")
+}
+
+// writesRecorder records every single write.
+type writesRecorder struct {
+	mu     sync.Mutex
+	writes []string
+}
+
+func (w *writesRecorder) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func TestColoredLogger_SingleWrite(t *testing.T) {
+	assert := assert.New(t)
+
+	out := &writesRecorder{}
+	formatter := FormatterFunc(func(buf *bytes.Buffer, rec *Record) error {
+		fmt.Fprintf(buf, "%v|%v\n", rec.Level, rec.Message)
+		return nil
+	})
+	plain := newTestSimpleLogger(LevelInfo, out)
+	colored1 := newTestColoredLogger(newTestSimpleLogger(LevelInfo, out))
+	colored2 := newTestColoredLogger(NewFormatterLogger(formatter))
+	colored2.SetOut(out)
+
+	var wg sync.WaitGroup
+	for _, logger := range []Logger{plain, colored1, colored2} {
+		wg.Add(1)
+		go func(logger Logger) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				logger.Warn("abc")
+			}
+		}(logger)
+	}
+	wg.Wait()
+
+	assert.Len(out.writes, 300, "Every message must be written with a single write")
+	for _, write := range out.writes {
+		switch write {
+		case "0001-01-01 00:00:00.000 [WARN] - abc\n",
+			string(ColorYellow) + "0001-01-01 00:00:00.000 [WARN] - abc\n" + string(ColorReset),
+			string(ColorYellow) + "WARN|abc\n" + string(ColorReset):
+		default:
+			assert.Fail("Unexpected write", "%q", write)
+		}
+	}
+}
+
+func TestColoredLogger_SetTheme(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	logger := newTestColoredLogger(newTestSimpleLogger(LevelVerbose, buf))
+
+	theme := ColorTheme{
+		LevelInfo: {Foreground: TrueColor(0, 128, 255), Bold: true},
+		LevelWarn: {Foreground: Color256(208), Background: BasicColor(0)},
+	}
+	logger.SetTheme(theme)
+	theme[LevelInfo] = ColorStyle{}
+	assert.Equal(ColorStyle{Foreground: TrueColor(0, 128, 255), Bold: true}, logger.Theme()[LevelInfo], "The theme must be copied")
+
+	logger.Info("abc")
+	logger.Warn("abc")
+	logger.Error("abc")
+	assert.Equal("\u001b[38;2;0;128;255;1m0001-01-01 00:00:00.000 [INFO] - abc\n\u001b[0m"+
+		"\u001b[38;5;208;40m0001-01-01 00:00:00.000 [WARN] - abc\n\u001b[0m"+
+		"\u001b[0m0001-01-01 00:00:00.000 [ERR ] - abc\n\u001b[0m", buf.String())
+
+	buf.Reset()
+	logger.With("request", 17).Warn("abc")
+	assert.Equal("\u001b[38;5;208;40m0001-01-01 00:00:00.000 [WARN] - abc request=17\n\u001b[0m", buf.String(), "Derived loggers must have the theme")
+}
+
+func TestColoredLogger_ColorMode(t *testing.T) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+
+	tests := []struct {
+		name     string
+		mode     ColorMode
+		out      io.Writer
+		env      map[string]string
+		expected bool
+	}{
+		{"no terminal", ColorModeAuto, &bytes.Buffer{}, nil, false},
+		{"terminal", ColorModeAuto, devNull, nil, true},
+		{"NO_COLOR", ColorModeAuto, devNull, map[string]string{"NO_COLOR": "1"}, false},
+		{"TERM=dumb", ColorModeAuto, devNull, map[string]string{"TERM": "dumb"}, false},
+		{"FORCE_COLOR", ColorModeAuto, &bytes.Buffer{}, map[string]string{"FORCE_COLOR": "1"}, true},
+		{"FORCE_COLOR before NO_COLOR", ColorModeAuto, &bytes.Buffer{}, map[string]string{"FORCE_COLOR": "1", "NO_COLOR": "1"}, true},
+		{"FORCE_COLOR=0", ColorModeAuto, devNull, map[string]string{"FORCE_COLOR": "0"}, false},
+		{"always", ColorModeAlways, &bytes.Buffer{}, map[string]string{"NO_COLOR": "1"}, true},
+		{"never", ColorModeNever, devNull, map[string]string{"FORCE_COLOR": "1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FORCE_COLOR", "")
+			t.Setenv("NO_COLOR", "")
+			t.Setenv("TERM", "xterm")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			logger := NewColoredLogger(newTestSimpleLogger(LevelInfo, tt.out)).(*ColoredLogger)
+			logger.SetColorMode(tt.mode)
+			assert.Equal(t, tt.expected, logger.ColorsEnabled())
+		})
+	}
+}
+
+func TestColoredLogger_ColorModeAuto(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Setenv("FORCE_COLOR", "")
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm")
+
+	buf := &bytes.Buffer{}
+	logger := NewColoredLogger(newTestSimpleLogger(LevelInfo, buf)).(*ColoredLogger)
+	assert.Equal(ColorModeAuto, logger.ColorMode())
+
+	logger.Info("abc")
+	assert.Equal("0001-01-01 00:00:00.000 [INFO] - abc\n", buf.String(), "No color codes must be written to a buffer")
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+
+	logger.SetOut(devNull)
+	assert.True(logger.ColorsEnabled(), "Setting a terminal as output must enable colors")
+	logger.SetOut(buf)
+	assert.False(logger.ColorsEnabled(), "Setting a buffer as output must disable colors")
+}
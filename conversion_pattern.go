@@ -0,0 +1,486 @@
+package abc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// compileConversionPattern compiles a conversion pattern as known
+// from log4j and logback, e.g.
+//
+//	%d{yyyy-MM-dd HH:mm:ss.SSS} [%-5p] %c{1} %F:%L - %m%n
+//
+// The syntax is described in the documentation of NewConversionPatternLogger.
+// Errors are of type *PatternError.
+func compileConversionPattern(pattern string) (*compiledPattern, error) {
+	var segments []patternSegment
+	needsCaller := false
+	text := ""
+	flushText := func() {
+		if text != "" {
+			t := text
+			segments = append(segments, func(buf *bytes.Buffer, _ *patternContext) {
+				buf.WriteString(t)
+			})
+			text = ""
+		}
+	}
+
+	for i := 0; i < len(pattern); {
+		next := strings.IndexByte(pattern[i:], '%')
+		if next < 0 {
+			text += pattern[i:]
+			break
+		}
+		text += pattern[i : i+next]
+		start := i + next
+		i = start + 1
+
+		if i < len(pattern) && pattern[i] == '%' {
+			text += "%"
+			i++
+			continue
+		}
+
+		// format modifier
+		leftAlign := false
+		if i < len(pattern) && pattern[i] == '-' {
+			leftAlign = true
+			i++
+		}
+		var min int
+		min, i = scanConversionNumber(pattern, i)
+		max, truncEnd := -1, false
+		if i < len(pattern) && pattern[i] == '.' {
+			i++
+			if i < len(pattern) && pattern[i] == '-' {
+				truncEnd = true
+				i++
+			}
+			maxStart := i
+			max, i = scanConversionNumber(pattern, i)
+			if i == maxStart {
+				return nil, newPatternError(pattern, maxStart, "missing maximum width after '.'")
+			}
+		}
+
+		// conversion word
+		wordStart := i
+		for i < len(pattern) && (pattern[i] >= 'a' && pattern[i] <= 'z' || pattern[i] >= 'A' && pattern[i] <= 'Z') {
+			i++
+		}
+		word := pattern[wordStart:i]
+		if word == "" {
+			return nil, newPatternError(pattern, wordStart, "missing conversion word after '%%'")
+		}
+
+		// option
+		option, optionStart := "", -1
+		if i < len(pattern) && pattern[i] == '{' {
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 {
+				return nil, newPatternError(pattern, i, "option of %%%v is not closed with '}'", word)
+			}
+			optionStart = i + 1
+			option = pattern[optionStart : i+end]
+			i += end + 1
+		}
+
+		segment, err := conversionWord(pattern, word, wordStart, option, optionStart)
+		if err != nil {
+			return nil, err
+		}
+		needsCaller = needsCaller || conversionNeedsCaller(word)
+		if min > 0 || max >= 0 {
+			segment = alignSegment(segment, min, leftAlign, max, truncEnd)
+		}
+		flushText()
+		segments = append(segments, segment)
+	}
+	flushText()
+
+	return &compiledPattern{
+		source:      pattern,
+		segments:    segments,
+		needsCaller: needsCaller,
+	}, nil
+}
+
+// scanConversionNumber scans a decimal number at the given offset
+// and returns it together with the offset after the number.
+// If there is no number, 0 is returned.
+func scanConversionNumber(pattern string, i int) (int, int) {
+	n := 0
+	for i < len(pattern) && pattern[i] >= '0' && pattern[i] <= '9' {
+		n = n*10 + int(pattern[i]-'0')
+		i++
+	}
+	return n, i
+}
+
+// conversionWord returns the segment of the given conversion word.
+// optionStart is the offset of the option in the pattern,
+// or -1 if the word has no option.
+func conversionWord(pattern, word string, wordStart int, option string, optionStart int) (patternSegment, error) {
+	noOption := func(segment patternSegment) (patternSegment, error) {
+		if optionStart >= 0 {
+			return nil, newPatternError(pattern, optionStart-1, "%%%v takes no option", word)
+		}
+		return segment, nil
+	}
+
+	switch word {
+	case "d", "date":
+		return dateConversion(pattern, option, optionStart)
+	case "p", "le", "level":
+		return noOption(writeLevelName)
+	case "c", "lo", "logger":
+		if optionStart < 0 {
+			return writeName, nil
+		}
+		n, err := strconv.Atoi(option)
+		if err != nil || n <= 0 {
+			return nil, newPatternError(pattern, optionStart, "option of %%%v must be a positive number", word)
+		}
+		return nameSegment(n), nil
+	case "F", "file":
+		return noOption(writeShortFile)
+	case "L", "line":
+		return noOption(writeLine)
+	case "M", "method":
+		return noOption(functionSegment("short"))
+	case "l":
+		return noOption(writeLocation)
+	case "m", "msg", "message":
+		return noOption(writeMessage)
+	case "n":
+		return noOption(func(buf *bytes.Buffer, _ *patternContext) {
+			buf.WriteByte('\n')
+		})
+	case "X", "mdc":
+		if optionStart < 0 {
+			return writeFields, nil
+		}
+		return fieldSegment(option), nil
+	}
+	return nil, newPatternError(pattern, wordStart, "unknown conversion word %q", word)
+}
+
+// conversionNeedsCaller returns whether the given conversion word
+// prints the file, line or function of the caller.
+func conversionNeedsCaller(word string) bool {
+	switch word {
+	case "F", "file", "L", "line", "M", "method", "l":
+		return true
+	}
+	return false
+}
+
+// dateTimeZoneComma returns the offset of the comma, that separates
+// the date format from the time zone in the given option of %d,
+// or -1 if the option has no time zone.
+// The text after the last comma is part of the date format, if it
+// contains spaces or quotes, or only letters of date formats, e.g.
+// in "EEE, d MMM" or "HH:mm:ss,SSS".
+func dateTimeZoneComma(option string) int {
+	i := strings.LastIndexByte(option, ',')
+	if i < 0 {
+		return -1
+	}
+	zone := strings.TrimSpace(option[i+1:])
+	if zone == "" || strings.ContainsAny(zone, " '") || strings.Trim(zone, javaPatternLetters) == "" {
+		return -1
+	}
+	return i
+}
+
+// javaPatternLetters are all pattern letters of java.text.SimpleDateFormat.
+const javaPatternLetters = "GyYMLwWDdFEuaHkKhmsSzZX"
+
+// Predefined date formats of log4j.
+var conversionDateFormats = map[string]string{
+	"":         "yyyy-MM-dd HH:mm:ss,SSS",
+	"ISO8601":  "yyyy-MM-dd HH:mm:ss,SSS",
+	"ABSOLUTE": "HH:mm:ss,SSS",
+	"DATE":     "dd MMM yyyy HH:mm:ss,SSS",
+}
+
+// dateConversion returns the segment of %d with the given option,
+// which is a date format of java.text.SimpleDateFormat, optionally
+// followed by a comma and a time zone, e.g. "HH:mm:ss.SSS, UTC".
+// An unknown time zone is an error.
+func dateConversion(pattern, option string, optionStart int) (patternSegment, error) {
+	format := option
+	var loc *time.Location
+	if i := dateTimeZoneComma(option); i >= 0 {
+		zone := strings.TrimSpace(option[i+1:])
+		l, err := time.LoadLocation(zone)
+		if err != nil {
+			zoneStart := i + 1 + strings.Index(option[i+1:], zone)
+			return nil, newPatternError(pattern, optionStart+zoneStart, "unknown time zone %q", zone)
+		}
+		format, loc = option[:i], l
+	}
+	if predefined, ok := conversionDateFormats[format]; ok {
+		format = predefined
+	}
+
+	parts, offset, err := javaDateLayout(format)
+	if err != nil {
+		return nil, newPatternError(pattern, optionStart+offset, "%v", err)
+	}
+
+	if loc == nil && len(parts) == 1 && parts[0].layout {
+		return timestampSegment(parts[0].text), nil
+	}
+	return func(buf *bytes.Buffer, ctx *patternContext) {
+		t := ctx.rec.Time
+		if loc != nil {
+			t = t.In(loc)
+		}
+		for _, part := range parts {
+			if !part.layout {
+				buf.WriteString(part.text)
+				continue
+			}
+			buf.Grow(len(part.text) + 16)
+			buf.Write(t.AppendFormat(buf.AvailableBuffer(), part.text))
+		}
+	}, nil
+}
+
+// Letters of java.text.SimpleDateFormat and the corresponding
+// parts of a go time layout, longest first.
+var javaDateLetters = []struct {
+	java, layout string
+}{
+	{"yyyy", "2006"}, {"yy", "06"},
+	{"MMMM", "January"}, {"MMM", "Jan"}, {"MM", "01"}, {"M", "1"},
+	{"EEEE", "Monday"}, {"EEE", "Mon"}, {"E", "Mon"},
+	{"dd", "02"}, {"d", "2"},
+	{"HH", "15"}, {"H", "15"}, {"hh", "03"}, {"h", "3"},
+	{"mm", "04"}, {"m", "4"},
+	{"ss", "05"}, {"s", "5"},
+	{"a", "PM"},
+	{"z", "MST"}, {"Z", "-0700"},
+	{"XXX", "Z07:00"}, {"XX", "Z0700"}, {"X", "Z07"},
+}
+
+// dateLayoutPart is either a go time layout or literal text,
+// that is printed as it is.
+type dateLayoutPart struct {
+	text   string
+	layout bool
+}
+
+// dateLayoutBuilder builds the parts of a converted date format.
+type dateLayoutBuilder struct {
+	parts []dateLayoutPart
+}
+
+// write appends the given text to the last part, if it is of the
+// same kind, or as a new part otherwise.
+func (b *dateLayoutBuilder) write(text string, layout bool) {
+	if n := len(b.parts); n > 0 && b.parts[n-1].layout == layout {
+		b.parts[n-1].text += text
+		return
+	}
+	b.parts = append(b.parts, dateLayoutPart{text: text, layout: layout})
+}
+
+// writeLiteral appends the given literal character. Characters that
+// can't be part of an element of a go time layout are added to the
+// layout, all others, e.g. letters and digits, are literal text.
+func (b *dateLayoutBuilder) writeLiteral(c byte) {
+	isElement := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c >= utf8.RuneSelf
+	b.write(string(c), !isElement)
+}
+
+// layoutHasSuffix returns whether the last part is a layout,
+// that ends with one of the given characters.
+func (b *dateLayoutBuilder) layoutHasSuffix(chars string) bool {
+	n := len(b.parts)
+	if n == 0 || !b.parts[n-1].layout {
+		return false
+	}
+	text := b.parts[n-1].text
+	return strings.IndexByte(chars, text[len(text)-1]) >= 0
+}
+
+// javaDateLayout converts a date format of java.text.SimpleDateFormat
+// to the parts of a go time layout and literal text, so that
+// literal text is never interpreted as an element of the layout.
+// If the format is invalid, the offset of the error in the format
+// is returned together with the error.
+func javaDateLayout(format string) ([]dateLayoutPart, int, error) {
+	var b dateLayoutBuilder
+	for i := 0; i < len(format); {
+		c := format[i]
+		switch {
+		case c == '\'':
+			// '' is a single quote, inside and outside of quoted text
+			if strings.HasPrefix(format[i+1:], "'") {
+				b.writeLiteral('\'')
+				i += 2
+				continue
+			}
+			start := i
+			for i++; ; i++ {
+				if i == len(format) {
+					return nil, start, errors.New("quoted text is not closed with '")
+				}
+				if format[i] != '\'' {
+					b.writeLiteral(format[i])
+				} else if strings.HasPrefix(format[i+1:], "'") {
+					b.writeLiteral('\'')
+					i++
+				} else {
+					break
+				}
+			}
+			i++
+		case c == 'S':
+			n := 0
+			for i+n < len(format) && format[i+n] == 'S' {
+				n++
+			}
+			if !b.layoutHasSuffix(".,") {
+				return nil, i, errors.New("fractions of a second (S) must follow '.' or ','")
+			}
+			b.write(strings.Repeat("0", n), true)
+			i += n
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			found := false
+			for _, letter := range javaDateLetters {
+				if strings.HasPrefix(format[i:], letter.java) {
+					b.write(letter.layout, true)
+					i += len(letter.java)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, i, fmt.Errorf("unsupported date letter %q", c)
+			}
+		default:
+			b.writeLiteral(c)
+			i++
+		}
+	}
+	return b.parts, 0, nil
+}
+
+// alignSegment returns a segment, that pads the output of the given
+// segment with spaces to at least min runes, and truncates it to
+// at most max runes, if max is not negative.
+// The output is padded on the left, unless leftAlign is true.
+// The output is truncated at the beginning, unless truncEnd is true.
+// The output is padded and truncated in place, without allocations.
+func alignSegment(segment patternSegment, min int, leftAlign bool, max int, truncEnd bool) patternSegment {
+	return func(buf *bytes.Buffer, ctx *patternContext) {
+		start := buf.Len()
+		segment(buf, ctx)
+		alignOutput(buf, start, min, leftAlign, max, truncEnd)
+	}
+}
+
+// alignOutput pads and truncates everything that was written to the
+// given buffer after start, as described in alignSegment.
+func alignOutput(buf *bytes.Buffer, start, min int, leftAlign bool, max int, truncEnd bool) {
+	out := buf.Bytes()[start:]
+	n := utf8.RuneCount(out)
+
+	if max >= 0 && n > max {
+		if truncEnd {
+			cut := 0
+			for i := 0; i < max; i++ {
+				_, size := utf8.DecodeRune(out[cut:])
+				cut += size
+			}
+			buf.Truncate(start + cut)
+		} else {
+			cut := 0
+			for i := 0; i < n-max; i++ {
+				_, size := utf8.DecodeRune(out[cut:])
+				cut += size
+			}
+			copy(out, out[cut:])
+			buf.Truncate(start + len(out) - cut)
+		}
+		n = max
+	}
+
+	if n >= min {
+		return
+	}
+	padding := min - n
+	for i := 0; i < padding; i++ {
+		buf.WriteByte(' ')
+	}
+	if !leftAlign {
+		out = buf.Bytes()[start:]
+		copy(out[padding:], out[:len(out)-padding])
+		for i := 0; i < padding; i++ {
+			out[i] = ' '
+		}
+	}
+}
+
+// writeLevelName writes the level without padding.
+func writeLevelName(buf *bytes.Buffer, ctx *patternContext) {
+	buf.WriteString(ctx.rec.Level.String())
+}
+
+func writeName(buf *bytes.Buffer, ctx *patternContext) {
+	buf.WriteString(ctx.rec.Name)
+}
+
+// nameSegment returns a segment that writes the last n
+// dot separated parts of the name of the logger.
+func nameSegment(n int) patternSegment {
+	return func(buf *bytes.Buffer, ctx *patternContext) {
+		name := ctx.rec.Name
+		i := len(name)
+		for parts := 0; parts < n && i >= 0; parts++ {
+			i = strings.LastIndexByte(name[:i], '.')
+		}
+		buf.WriteString(name[i+1:])
+	}
+}
+
+var (
+	writePackageFunction = functionSegment("package")
+	writeShortFile       = fileSegment("short")
+)
+
+// writeLocation writes the function, file and line of the caller,
+// e.g. "main.main(main.go:16)".
+func writeLocation(buf *bytes.Buffer, ctx *patternContext) {
+	writePackageFunction(buf, ctx)
+	buf.WriteByte('(')
+	writeShortFile(buf, ctx)
+	buf.WriteByte(':')
+	writeLine(buf, ctx)
+	buf.WriteByte(')')
+}
+
+// fieldSegment returns a segment that writes the value of the
+// last field with the given key, or nothing if there is no such field.
+func fieldSegment(key string) patternSegment {
+	return func(buf *bytes.Buffer, ctx *patternContext) {
+		for i := len(ctx.rec.Fields) - 1; i >= 0; i-- {
+			if ctx.rec.Fields[i].Key == key {
+				field := ctx.field
+				ctx.field = &ctx.rec.Fields[i]
+				writeFieldValue(buf, ctx)
+				ctx.field = field
+				return
+			}
+		}
+	}
+}
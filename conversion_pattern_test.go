@@ -0,0 +1,105 @@
+package abc
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileConversionPattern(t *testing.T) {
+	rec := &Record{
+		Time:    time.Date(2018, 11, 24, 15, 26, 44, 453000000, time.UTC),
+		Level:   LevelWarn,
+		Name:    "app.db.Conn",
+		Message: "Hello World!",
+		Fields:  Fields{{"request", 17}, {"user", "John Doe"}},
+	}
+
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"plain text", "plain text"},
+		{"%d{yyyy-MM-dd HH:mm:ss.SSS} [%-5p] %c{1} - %m%n", "2018-11-24 15:26:44.453 [WARN ] Conn - Hello World!\n"},
+		{CustomPatternLoggerDefaultConversionPattern, "2018-11-24 15:26:44.453 [WARN] - Hello World!\n"},
+		{"%date %d{ISO8601} %d{ABSOLUTE} %d{DATE}", "2018-11-24 15:26:44,453 2018-11-24 15:26:44,453 15:26:44,453 24 Nov 2018 15:26:44,453"},
+		{"%d{EEE, d MMM yy h:mm a 'o''clock'}", "Sat, 24 Nov 18 3:26 PM o'clock"},
+		{"%d{HH:mm:ss.SSS, America/New_York}", "10:26:44.453"},
+		{"%d{'Mon' 'day' HH}", "Mon day 15"},
+		{"%d{yyyy-MM-dd'T'HH:mm 'at' '1' 2}", "2018-11-24T15:26 at 1 2"},
+		{"%d{'Jan' MMM '2006'_hh, UTC}", "Jan Nov 2006_03"},
+		{"%d{HH:mm:ss,SSS}|%d{ISO8601, Asia/Tokyo}", "15:26:44,453|2018-11-25 00:26:44,453"},
+		{"%p|%le|%level|%5p|%-5p|%.2p|%.-2p|%-6.2p", "WARN|WARN|WARN| WARN|WARN |RN|WA|RN    "},
+		{"%c|%lo{2}|%logger{5}|%.6c|%.-6c", "app.db.Conn|db.Conn|app.db.Conn|b.Conn|app.db"},
+		{"%m|%msg|%message|%.3m|%.-3m|%14m", "Hello World!|Hello World!|Hello World!|ld!|Hel|  Hello World!"},
+		{"%X|%X{user}|%mdc{request}|%X{unknown}", `request=17 user="John Doe"|John Doe|17|`},
+		{"100%% %n", "100% \n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			assert := assert.New(t)
+
+			p, err := compileConversionPattern(tt.pattern)
+			if assert.NoError(err) {
+				buf := &bytes.Buffer{}
+				p.execute(buf, rec)
+				assert.Equal(tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestCompileConversionPattern_Caller(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	logger, err := NewConversionPatternLogger("%F:%L %M %l - %m")
+	assert.NoError(err)
+	logger.SetOut(buf)
+
+	line := nextLine()
+	logger.Info("Hello")
+	assert.Equal(fmt.Sprintf("conversion_pattern_test.go:%[1]v TestCompileConversionPattern_Caller abc.TestCompileConversionPattern_Caller(conversion_pattern_test.go:%[1]v) - Hello", line), buf.String())
+}
+
+func TestCompileConversionPattern_Errors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"%", "pattern:1:2: missing conversion word after '%'"},
+		{"[%-5]", "pattern:1:5: missing conversion word after '%'"},
+		{"%5.p", "pattern:1:4: missing maximum width after '.'"},
+		{"%foo", `pattern:1:2: unknown conversion word "foo"`},
+		{"%d{yyyy", "pattern:1:3: option of %d is not closed with '}'"},
+		{"%d{yyyy-MM-dd Q}", `pattern:1:15: unsupported date letter 'Q'`},
+		{"%d{HH:mm:SSS}", "pattern:1:10: fractions of a second (S) must follow '.' or ','"},
+		{"%d{'abc}", "pattern:1:4: quoted text is not closed with '"},
+		{"%d{HH:mm, Mars/Olympus}", `pattern:1:11: unknown time zone "Mars/Olympus"`},
+		{"%d{ISO8601,UTX}", `pattern:1:12: unknown time zone "UTX"`},
+		{"%m{x}", "pattern:1:3: %m takes no option"},
+		{"%c{0}", "pattern:1:4: option of %c must be a positive number"},
+		{"äöü\n  %c{x}", "pattern:2:6: option of %c must be a positive number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			assert := assert.New(t)
+
+			_, err := compileConversionPattern(tt.pattern)
+			assert.EqualError(err, tt.want)
+			_, ok := err.(*PatternError)
+			assert.True(ok, "Errors must be of type *PatternError")
+		})
+	}
+}
+
+func TestNewConversionPatternLogger_InvalidPattern(t *testing.T) {
+	assert := assert.New(t)
+
+	logger, err := NewConversionPatternLogger("%foo")
+	assert.EqualError(err, `pattern:1:2: unknown conversion word "foo"`)
+	assert.Equal(CustomPatternLoggerDefaultConversionPattern, logger.(*CustomPatternLogger).Pattern(), "The default pattern must be used")
+}
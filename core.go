@@ -0,0 +1,563 @@
+package abc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// core implements the level methods of abc.Logger for all
+// loggers of this package.
+// For every message that passes the level check, it builds
+// a Record and passes it to its handler.
+//
+// The configuration, that can be changed after the logger was
+// created, is stored in atomics, so that the level check
+// of a disabled level neither locks nor allocates.
+type core struct {
+	lvl atomic.Int32
+	clk atomic.Pointer[clock]
+	// name is nil if the logger has no name.
+	name atomic.Pointer[string]
+
+	fields Fields
+
+	// caller indicates whether the PC of the log call
+	// is recorded.
+	caller atomic.Bool
+	// skip is the number of frames outside of this package,
+	// that are skipped when the caller is detected.
+	skip int
+
+	handler Handler
+	// levelHandler is the handler, if it is a LevelHandler,
+	// or nil otherwise.
+	levelHandler LevelHandler
+}
+
+func (c *core) print(lvl LogLevel, v ...interface{}) {
+	if c.IsLevelEnabled(lvl) {
+		c.log(lvl, fmt.Sprint(v...))
+	}
+}
+
+func (c *core) printf(lvl LogLevel, format string, v ...interface{}) {
+	if c.IsLevelEnabled(lvl) {
+		c.log(lvl, fmt.Sprintf(format, v...))
+	}
+}
+
+// log builds a record and passes it to the handler.
+func (c *core) log(lvl LogLevel, msg string) {
+	rec := c.newRecord(lvl, msg)
+	_ = c.handler.Handle(rec)
+	freeRecord(rec)
+}
+
+// newRecord builds a record of the given message with the time,
+// name and fields of this logger, and the caller of the log call,
+// if this logger records it.
+// Records are pooled, since handlers must not retain them, and
+// must be returned with freeRecord.
+func (c *core) newRecord(lvl LogLevel, msg string) *Record {
+	rec := recordPool.Get().(*Record)
+	*rec = Record{
+		Time:    c.clock().Now(),
+		Level:   lvl,
+		Message: msg,
+		Name:    c.getName(),
+		Fields:  c.fields,
+	}
+	if c.recordsCaller() {
+		rec.PC = callerPC(c.skip)
+	}
+	return rec
+}
+
+// freeRecord returns a record, that was built with newRecord,
+// to the pool.
+func freeRecord(rec *Record) {
+	*rec = Record{}
+	recordPool.Put(rec)
+}
+
+// Log logs the given record, if and only if its level is
+// enabled by this logger.
+// The name and the fields of this logger are added to the record.
+// If the time of the record is zero, the clock of this logger is used.
+func (c *core) Log(rec *Record) error {
+	if !c.IsLevelEnabled(rec.Level) {
+		return nil
+	}
+
+	return c.logAnyLevel(rec)
+}
+
+// logAnyLevel logs the given record like Log, but regardless
+// of its level.
+func (c *core) logAnyLevel(rec *Record) error {
+	c.completeRecord(rec)
+	return c.handler.Handle(rec)
+}
+
+// completeRecord adds the name and the fields of this logger to
+// the given record, and the time, if the record has none.
+func (c *core) completeRecord(rec *Record) {
+	if rec.Time.IsZero() {
+		rec.Time = c.clock().Now()
+	}
+	if rec.Name == "" {
+		rec.Name = c.getName()
+	}
+	if len(c.fields) > 0 {
+		rec.Fields = append(c.fields[:len(c.fields):len(c.fields)], rec.Fields...)
+	}
+}
+
+// Print prints the given values with the given log level,
+// if and only if the given log level is higher than or
+// equal to the one of this logger.
+func (c *core) Print(lvl LogLevel, v ...interface{}) {
+	c.print(lvl, v...)
+}
+
+// Printf formats and prints the given values with the given log level,
+// if and only if the given log level is higher than or
+// equal to the one of this logger.
+func (c *core) Printf(lvl LogLevel, format string, v ...interface{}) {
+	c.printf(lvl, format, v...)
+}
+
+// Verbose prints the given values with log level DEBG,
+// if and only if this logger has the verbose log level enabled.
+func (c *core) Verbose(v ...interface{}) {
+	c.print(LevelVerbose, v...)
+}
+
+// Verbosef formats and prints the given values with log level DEBG,
+// if and only if this logger has the verbose log level enabled.
+func (c *core) Verbosef(format string, v ...interface{}) {
+	c.printf(LevelVerbose, format, v...)
+}
+
+// Debug prints the given values with log level DEBG.
+func (c *core) Debug(v ...interface{}) {
+	c.print(LevelDebug, v...)
+}
+
+// Debugf formats and prints the given values with log level DEBG.
+func (c *core) Debugf(format string, v ...interface{}) {
+	c.printf(LevelDebug, format, v...)
+}
+
+// Info prints the given values with log level INFO.
+func (c *core) Info(v ...interface{}) {
+	c.print(LevelInfo, v...)
+}
+
+// Infof formats and prints the given values with log level INFO.
+func (c *core) Infof(format string, v ...interface{}) {
+	c.printf(LevelInfo, format, v...)
+}
+
+// Warn prints the given values with log level WARN.
+func (c *core) Warn(v ...interface{}) {
+	c.print(LevelWarn, v...)
+}
+
+// Warnf formats and prints the given values with log level WARN.
+func (c *core) Warnf(format string, v ...interface{}) {
+	c.printf(LevelWarn, format, v...)
+}
+
+// Error prints the given values with log level ERR.
+func (c *core) Error(v ...interface{}) {
+	c.print(LevelError, v...)
+}
+
+// Errorf formats and prints the given values with log level ERR.
+func (c *core) Errorf(format string, v ...interface{}) {
+	c.printf(LevelError, format, v...)
+}
+
+// Fatal prints the given values with log level FATAL.
+// IT DOES NOT TERMINATE THE APPLICATION.
+func (c *core) Fatal(v ...interface{}) {
+	c.print(LevelFatal, v...)
+}
+
+// Fatalf formats and prints the given values with log level FATAL.
+// IT DOES NOT TERMINATE THE APPLICATION.
+func (c *core) Fatalf(format string, v ...interface{}) {
+	c.printf(LevelFatal, format, v...)
+}
+
+// Level returns the current level of this logger.
+func (c *core) Level() LogLevel {
+	return LogLevel(c.lvl.Load())
+}
+
+// SetLevel changes the log level of this logger.
+func (c *core) SetLevel(lvl LogLevel) {
+	c.lvl.Store(int32(lvl))
+}
+
+// SetLevelString changes to log level of this logger.
+// See ToLogLevel for the accepted values.
+func (c *core) SetLevelString(level string) {
+	c.SetLevel(ToLogLevel(level))
+}
+
+// IsLevelEnabled returns true if and only if this logger would print
+// messages with the given log level.
+// False otherwise.
+func (c *core) IsLevelEnabled(lvl LogLevel) bool {
+	return lvl >= c.Level() && (c.levelHandler == nil || c.levelHandler.Enabled(lvl))
+}
+
+// setHandler sets the handler of this logger.
+func (c *core) setHandler(handler Handler) {
+	c.handler = handler
+	c.levelHandler, _ = handler.(LevelHandler)
+}
+
+// clock returns the clock of this logger.
+func (c *core) clock() clock {
+	return *c.clk.Load()
+}
+
+// SetClock sets a new clock for this logger.
+func (c *core) SetClock(clk clock) {
+	c.clk.Store(&clk)
+}
+
+func (c *core) getName() string {
+	if name := c.name.Load(); name != nil {
+		return *name
+	}
+	return ""
+}
+
+func (c *core) setName(name string) {
+	c.name.Store(&name)
+}
+
+// derive initializes dst with the configuration of c and the
+// fields of c, extended by the given key/value pairs.
+// The handler of dst is not set.
+func (c *core) derive(dst *core, keyvals ...interface{}) {
+	dst.lvl.Store(c.lvl.Load())
+	dst.clk.Store(c.clk.Load())
+	dst.name.Store(c.name.Load())
+	dst.fields = c.fields.with(keyvals...)
+	dst.caller.Store(c.caller.Load())
+	dst.skip = c.skip
+}
+
+func (c *core) recordsCaller() bool {
+	return c.caller.Load()
+}
+
+func (c *core) setRecordsCaller(caller bool) {
+	c.caller.Store(caller)
+}
+
+// addCallerSkip increases the number of frames, that are
+// skipped when the caller is detected, by n.
+func (c *core) addCallerSkip(n int) {
+	c.skip += n
+}
+
+// writerCore is the core of all WriterLoggers of this package.
+// It handles the records that its core builds by formatting
+// them with its formatter and writing them to its writer
+// with a single write.
+type writerCore struct {
+	core
+	writeErrors
+
+	cfg atomic.Pointer[writerConfig]
+}
+
+// writeErrors reports and redirects the failed writes of a logger.
+type writeErrors struct {
+	errMux     sync.Mutex
+	onWriteErr func(error)
+	fallback   io.Writer
+	// failed is the number of failed writes, which is shared
+	// with all loggers derived with With.
+	failed *uint64
+}
+
+// writerConfig is the writer and the formatter of a writerCore.
+// It is immutable and replaced as a whole whenever one of them
+// changes, so that every record is formatted and written with
+// a consistent configuration.
+type writerConfig struct {
+	out       io.Writer
+	formatter Formatter
+
+	// colorMode and theme decide, whether and with which colors
+	// formatters, that support colors, color parts of their output.
+	colorMode ColorMode
+	theme     *compiledTheme
+	// colors are the escape codes of the theme, or nil if no
+	// colors are written to out in colorMode.
+	colors []color
+}
+
+// resolveColors decides whether colors are written to the
+// writer of the configuration.
+func (cfg *writerConfig) resolveColors() {
+	cfg.colors = nil
+	if colorsEnabled(cfg.colorMode, cfg.out) {
+		cfg.colors = cfg.theme.codes
+	}
+}
+
+// colorFormatter is implemented by the formatters of this package,
+// that color parts of their output.
+type colorFormatter interface {
+	// formatColored formats like Format and colors parts of the output
+	// with the given escape codes of the levels, unless they are nil.
+	formatColored(buf *bytes.Buffer, rec *Record, colors []color) error
+}
+
+// messageFormatter is implemented by the loggers of this package,
+// that can format their messages into a buffer exactly like they
+// would write them, so that a ColoredLogger can color the output
+// and write it with a single write.
+// Loggers that wrap other loggers return errCannotFormat, if the
+// wrapped logger can't format its messages, before they write
+// anything to the buffer.
+type messageFormatter interface {
+	// formatMessage formats a record of the given message, like a
+	// log call of the logger would.
+	formatMessage(buf *bytes.Buffer, lvl LogLevel, msg string) error
+	// formatRecord formats the given record like Log would,
+	// but regardless of its level.
+	formatRecord(buf *bytes.Buffer, rec *Record) error
+}
+
+// errCannotFormat is returned by a messageFormatter, that wraps
+// a logger, which can't format its messages into a buffer.
+var errCannotFormat = errors.New("messages of the wrapped logger can't be formatted")
+
+// configure initializes the writer core with the given configuration.
+// The writer core becomes its own handler.
+func (w *writerCore) configure(lvl LogLevel, clk clock, out io.Writer, formatter Formatter) {
+	w.SetLevel(lvl)
+	w.SetClock(clk)
+	w.cfg.Store(&writerConfig{
+		out:       out,
+		formatter: formatter,
+		colorMode: ColorModeNever,
+		theme:     defaultTheme,
+	})
+	w.failed = new(uint64)
+	w.setHandler(w)
+}
+
+// updateConfig replaces the configuration of this writer core
+// with a copy, that was changed with the given function.
+func (w *writerCore) updateConfig(update func(cfg *writerConfig)) {
+	for {
+		old := w.cfg.Load()
+		cfg := *old
+		update(&cfg)
+		cfg.resolveColors()
+		if w.cfg.CompareAndSwap(old, &cfg) {
+			return
+		}
+	}
+}
+
+// Handle formats the given record and writes it to the
+// writer of this logger.
+func (w *writerCore) Handle(rec *Record) error {
+	cfg := w.cfg.Load()
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := cfg.format(buf, rec); err != nil {
+		return err
+	}
+	if _, err := cfg.out.Write(buf.Bytes()); err != nil {
+		w.writeFailed(err, buf.Bytes())
+		return err
+	}
+	return nil
+}
+
+// format formats the given record with the formatter of this
+// configuration, which colors parts of the output, if it supports
+// colors and colors are written.
+func (cfg *writerConfig) format(buf *bytes.Buffer, rec *Record) error {
+	if f, ok := cfg.formatter.(colorFormatter); ok && cfg.colors != nil {
+		return f.formatColored(buf, rec, cfg.colors)
+	}
+	return cfg.formatter.Format(buf, rec)
+}
+
+// formatMessage formats a record of the given message like a log
+// call of this logger, but regardless of the level.
+func (w *writerCore) formatMessage(buf *bytes.Buffer, lvl LogLevel, msg string) error {
+	rec := w.newRecord(lvl, msg)
+	defer freeRecord(rec)
+
+	return w.cfg.Load().format(buf, rec)
+}
+
+// formatRecord formats the given record like Log, but regardless
+// of its level.
+func (w *writerCore) formatRecord(buf *bytes.Buffer, rec *Record) error {
+	w.completeRecord(rec)
+	return w.cfg.Load().format(buf, rec)
+}
+
+// writeFailed counts a failed write, reports the error to the
+// write error callback and writes the given bytes to the
+// fallback writer, if there is one.
+func (e *writeErrors) writeFailed(err error, p []byte) {
+	atomic.AddUint64(e.failed, 1)
+
+	e.errMux.Lock()
+	onWriteErr, fallback := e.onWriteErr, e.fallback
+	e.errMux.Unlock()
+
+	if onWriteErr != nil {
+		onWriteErr(err)
+	}
+	if fallback != nil {
+		_, _ = fallback.Write(p)
+	}
+}
+
+// OnWriteError sets a function, that is called with the error
+// of every failed write.
+// The function is called synchronously, so it must not log
+// with this logger.
+func (e *writeErrors) OnWriteError(fn func(error)) {
+	e.errMux.Lock()
+	defer e.errMux.Unlock()
+
+	e.onWriteErr = fn
+}
+
+// SetFallback sets a writer, to which messages are written,
+// if the write to the writer of this logger fails, e.g. os.Stderr.
+// If the fallback is nil, failed messages are lost.
+func (e *writeErrors) SetFallback(fallback io.Writer) {
+	e.errMux.Lock()
+	defer e.errMux.Unlock()
+
+	e.fallback = fallback
+}
+
+// FailedWrites returns the number of failed writes of this logger
+// and all loggers that were derived from it with With.
+func (e *writeErrors) FailedWrites() uint64 {
+	return atomic.LoadUint64(e.failed)
+}
+
+// deriveWriteErrors initializes dst with the write error callback
+// and the fallback writer of e, and shares the failed write counter.
+func (e *writeErrors) deriveWriteErrors(dst *writeErrors) {
+	e.errMux.Lock()
+	dst.onWriteErr = e.onWriteErr
+	dst.fallback = e.fallback
+	e.errMux.Unlock()
+	dst.failed = e.failed
+}
+
+// derive initializes dst with the configuration of w and the
+// fields of w, extended by the given key/value pairs.
+// dst becomes its own handler.
+func (w *writerCore) derive(dst *writerCore, keyvals ...interface{}) {
+	w.core.derive(&dst.core, keyvals...)
+	dst.cfg.Store(w.cfg.Load())
+	w.deriveWriteErrors(&dst.writeErrors)
+	dst.setHandler(dst)
+}
+
+// Out returns the writer of this logger.
+func (w *writerCore) Out() io.Writer {
+	return w.cfg.Load().out
+}
+
+// SetOut sets a new writer for this logger.
+func (w *writerCore) SetOut(out io.Writer) {
+	w.updateConfig(func(cfg *writerConfig) {
+		cfg.out = out
+	})
+}
+
+func (w *writerCore) getFormatter() Formatter {
+	return w.cfg.Load().formatter
+}
+
+func (w *writerCore) setFormatter(formatter Formatter) {
+	w.updateConfig(func(cfg *writerConfig) {
+		cfg.formatter = formatter
+	})
+}
+
+func (w *writerCore) getColorMode() ColorMode {
+	return w.cfg.Load().colorMode
+}
+
+func (w *writerCore) setColorMode(mode ColorMode) {
+	w.updateConfig(func(cfg *writerConfig) {
+		cfg.colorMode = mode
+	})
+}
+
+func (w *writerCore) getTheme() ColorTheme {
+	return w.cfg.Load().theme.theme.clone()
+}
+
+func (w *writerCore) setTheme(theme ColorTheme) {
+	compiled := compileTheme(theme)
+	w.updateConfig(func(cfg *writerConfig) {
+		cfg.theme = compiled
+	})
+}
+
+func (w *writerCore) colorsEnabled() bool {
+	return w.cfg.Load().colors != nil
+}
+
+// Sync commits the output of this logger to stable storage,
+// if the writer of this logger implements Syncer.
+// os.Stdout and os.Stderr are never synced.
+func (w *writerCore) Sync() error {
+	return syncWriter(w.Out())
+}
+
+// Close closes the writer of this logger, if it implements io.Closer,
+// and syncs it otherwise.
+// os.Stdout and os.Stderr are never closed.
+func (w *writerCore) Close() error {
+	return closeWriter(w.Out())
+}
+
+// syncWriter syncs the given writer, if it implements Syncer
+// and is neither os.Stdout nor os.Stderr.
+func syncWriter(out io.Writer) error {
+	if s, ok := out.(Syncer); ok && !isStdStream(out) {
+		return s.Sync()
+	}
+	return nil
+}
+
+// closeWriter closes the given writer, if it implements io.Closer
+// and is neither os.Stdout nor os.Stderr, and syncs it otherwise.
+func closeWriter(out io.Writer) error {
+	if c, ok := out.(io.Closer); ok && !isStdStream(out) {
+		return c.Close()
+	}
+	return syncWriter(out)
+}
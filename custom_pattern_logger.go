@@ -2,14 +2,8 @@ package abc
 
 import (
 	"bytes"
-	"fmt"
-	"io"
-	"path/filepath"
-	"runtime"
-	"strings"
+	"os"
 	"sync"
-	"sync/atomic"
-	"text/template"
 )
 
 const (
@@ -17,15 +11,22 @@ const (
 	// uses for its messages if no formatting pattern is given.
 	TimeLayoutCustomPatternLogger = "2006-01-02 15:04:05.000"
 	// CustomPatternLoggerDefaultPattern is the fallback pattern that is used
-	// if the pattern compilation or template execution fails.
+	// if the given pattern is invalid.
 	CustomPatternLoggerDefaultPattern = "{{.Timestamp}} [{{.Level}}] - {{.Message}}\n"
+	// CustomPatternLoggerDefaultConversionPattern is the fallback pattern
+	// of loggers that were created with NewConversionPatternLogger,
+	// which is used if the given pattern is invalid.
+	// It prints the same as CustomPatternLoggerDefaultPattern.
+	CustomPatternLoggerDefaultConversionPattern = "%d{yyyy-MM-dd HH:mm:ss.SSS} [%-4p] - %m%n"
 )
 
 // CustomPatternLogger is a logger that supports a custom
 // log pattern.
-// The pattern is a go template text.
-// If its compilation or execution fails at any point,
-// the CustomPatternLoggerDefaultPattern will be used.
+// The pattern uses the syntax of go templates and is compiled
+// once, when the logger is created, and again when it is
+// replaced with SetPattern.
+// If the pattern is invalid, the CustomPatternLoggerDefaultPattern
+// will be used.
 // It supports the
 // following operations:
 //
@@ -37,6 +38,11 @@ const (
 // If the message or the pattern doesn't end with a line break,
 // no line break will be printed.
 //
+//	{{.Fields}} // the fields that were attached with With
+// Fields prints the fields of the logger in the form key=value,
+// separated by spaces. Fields can also be iterated with
+// {{range .Fields}}{{.Key}}: {{.Value}}{{end}}.
+//
 //	{{.Timestamp}} or {{.Timestampf "2006-01-02 03:04:05PM"}} // time.Time.Format's layout is used
 // Timestampf takes a string argument, which will be used for formatting
 // the timestamp in the log message. The reference time is the same as in
@@ -54,6 +60,12 @@ const (
 // Functionf "short" prints only the function name, while Functionf "package"
 // will print <package>.<function>.
 // Functionf "full" will print <full_package>.<function>, e.g. "github.com/TimSatke/abc.main".
+// The caller is only detected, if the pattern prints its file,
+// line or function.
+//
+// The verbs for the name, fields, host, process and goroutine,
+// as well as pipelines, functions and the other constructs of go
+// templates are described at NewCustomPatternLogger.
 //
 // Example:
 //
@@ -64,274 +76,157 @@ const (
 //	2018-11-24 15:26:44.453 main.go:16 main.main [INFO] - Hello World!
 //	<line break>
 type CustomPatternLogger struct {
-	lvlMux sync.Mutex
-	lvl    LogLevel
-
-	clockMux sync.Mutex
-	clk      clock
-
-	outMux sync.Mutex
-	out    io.Writer
-
-	pattern  string
-	lock     sync.Mutex
-	template *template.Template
+	writerCore
 }
 
-// Print prints the given values with the given log level,
-// if and only if the given log level is higher than or
-// equal to the one of this logger.
-func (l *CustomPatternLogger) Print(lvl LogLevel, v ...interface{}) {
-	l.print0(lvl, v...)
+// customPatternFormatter is the formatter of the CustomPatternLogger,
+// which executes the compiled pattern of the logger.
+type customPatternFormatter struct {
+	pattern *compiledPattern
+	// compile compiles patterns in the syntax of the pattern,
+	// with the functions of the pattern.
+	compile func(pattern string) (*compiledPattern, error)
+	// onErr is shared with all loggers derived with With.
+	onErr *patternErrorHook
+	// seq is the counter of {{.Seq}}, which is shared with all
+	// loggers derived with With, and kept by SetPattern.
+	seq *uint64
 }
 
-// Printf formats and prints the given values with the given log level,
-// if and only if the given log level is higher than or
-// equal to the one of this logger.
-func (l *CustomPatternLogger) Printf(lvl LogLevel, format string, v ...interface{}) {
-	l.printf0(lvl, format, v...)
+// patternErrorHook is the function, that is called with errors
+// that occur while a pattern is executed.
+type patternErrorHook struct {
+	mu sync.Mutex
+	fn func(error)
 }
 
-func (l *CustomPatternLogger) print0(lvl LogLevel, v ...interface{}) {
-	if l.IsLevelEnabled(lvl) {
-		l.print1(l.prepareMessage(lvl, fmt.Sprint(v...)))
-	}
-}
-func (l *CustomPatternLogger) printf0(lvl LogLevel, format string, v ...interface{}) {
-	if l.IsLevelEnabled(lvl) {
-		l.print1(l.prepareMessage(lvl, fmt.Sprintf(format, v...)))
-	}
+func (f customPatternFormatter) Format(buf *bytes.Buffer, rec *Record) error {
+	return f.formatColored(buf, rec, nil)
 }
 
-func (l *CustomPatternLogger) prepareMessage(lvl LogLevel, a string) string {
-	if l.template == nil {
-		err := l.init()
-		if err != nil {
-			println(fmt.Sprintf("Failed to initialize logger, using default pattern: %v", err))
-			l.pattern = CustomPatternLoggerDefaultPattern
-			_ = l.init()
+// formatColored passes the colors to the color function of the pattern.
+func (f customPatternFormatter) formatColored(buf *bytes.Buffer, rec *Record, colors []color) error {
+	if err := f.pattern.executeColored(buf, rec, colors, f.seq); err != nil {
+		f.onErr.mu.Lock()
+		fn := f.onErr.fn
+		f.onErr.mu.Unlock()
+
+		if fn != nil {
+			fn(err)
 		}
 	}
+	return nil
+}
 
-	buf := &bytes.Buffer{}
-	err := l.template.Execute(buf, &customPatternLoggerTemplateData{
-		clk:     l.clk,
-		Level:   fmt.Sprintf("%-4v", lvl.String()),
-		Message: a,
-	})
+// newCustomPatternLogger creates a new CustomPatternLogger, that
+// compiles its patterns with the given function.
+// If the pattern is invalid, the fallback pattern is used and the
+// error is returned together with the logger.
+func newCustomPatternLogger(pattern, fallback string, compile func(string) (*compiledPattern, error)) (*CustomPatternLogger, error) {
+	compiled, err := compile(pattern)
 	if err != nil {
-		println(fmt.Sprintf("Failed to execute template, using default pattern: %v", err))
-		l.pattern = CustomPatternLoggerDefaultPattern
-		l.template = nil
-		return l.prepareMessage(lvl, a) // recursive call, with default pattern, which will be compiled in recursive call
+		compiled, _ = compile(fallback)
 	}
-	return buf.String() // TODO(TimSatke) implement message formatting
-}
 
-func (l *CustomPatternLogger) init() error {
-	// TODO(TimSatke) make safe for concurrent use
-	tmpl, err := template.New(l.pattern).Parse(l.pattern)
+	logger := &CustomPatternLogger{}
+	logger.configure(LevelInfo, &realClock{}, os.Stdout, customPatternFormatter{
+		pattern: compiled,
+		compile: compile,
+		onErr:   &patternErrorHook{},
+		seq:     new(uint64),
+	})
+	logger.setRecordsCaller(compiled.needsCaller)
+	return logger, err
+}
+
+// With returns a new CustomPatternLogger that makes the given key/value
+// pairs available as {{.Fields}} in the pattern, in addition to the
+// fields of this logger.
+// The new logger starts with the level, clock, writer and pattern of this logger.
+func (l *CustomPatternLogger) With(keyvals ...interface{}) Logger {
+	derived := &CustomPatternLogger{}
+	l.derive(&derived.writerCore, keyvals...)
+	return derived
+}
+
+// Pattern returns the pattern of this logger.
+func (l *CustomPatternLogger) Pattern() string {
+	return l.getFormatter().(customPatternFormatter).pattern.source
+}
+
+// SetPattern compiles the given pattern and replaces the pattern
+// of this logger with it.
+// The pattern must have the syntax of the current pattern, i.e.
+// a template, or a conversion pattern if the logger was created
+// with NewConversionPatternLogger, and can use the same functions.
+// If the pattern is invalid, a *PatternError is returned and the
+// current pattern is kept.
+// Messages are printed either completely with the old or completely
+// with the new pattern, even if they are logged concurrently.
+// Loggers that were derived from this logger with With keep
+// their pattern.
+func (l *CustomPatternLogger) SetPattern(pattern string) error {
+	f := l.getFormatter().(customPatternFormatter)
+	compiled, err := f.compile(pattern)
 	if err != nil {
 		return err
 	}
-
-	l.template = tmpl
+	f.pattern = compiled
+	if compiled.needsCaller {
+		// record the caller before the pattern prints it
+		l.setRecordsCaller(true)
+	}
+	l.setFormatter(f)
+	l.setRecordsCaller(compiled.needsCaller)
 	return nil
 }
 
-func (l *CustomPatternLogger) print1(a string) {
-	io.WriteString(l.out, a)
-}
-
-// Verbose prints the given values with log level DEBG,
-// if and only if this logger has the verbose log level enabled.
-func (l *CustomPatternLogger) Verbose(v ...interface{}) {
-	l.print0(LevelVerbose, v...)
-}
-
-// Verbosef formats and prints the given values with log level DEBG,
-// if and only if this logger has the verbose log level enabled.
-func (l *CustomPatternLogger) Verbosef(format string, v ...interface{}) {
-	l.printf0(LevelVerbose, format, v...)
-}
-
-// Debug prints the given values with log level DEBG.
-func (l *CustomPatternLogger) Debug(v ...interface{}) {
-	l.print0(LevelDebug, v...)
-}
-
-// Debugf formats and prints the given values with log level DEBG.
-func (l *CustomPatternLogger) Debugf(format string, v ...interface{}) {
-	l.printf0(LevelDebug, format, v...)
-}
-
-// Info prints the given values with log level INFO.
-func (l *CustomPatternLogger) Info(v ...interface{}) {
-	l.print0(LevelInfo, v...)
-}
-
-// Infof formats and prints the given values with log level INFO.
-func (l *CustomPatternLogger) Infof(format string, v ...interface{}) {
-	l.printf0(LevelInfo, format, v...)
-}
+// OnPatternError sets a function, that is called with every error
+// that occurs while the pattern is executed, e.g. if a template
+// function returns an error or panics.
+// The message is printed anyway, with the error in place of the
+// output of the failed function.
+// The function is used by this logger and all loggers that were
+// derived from it with With.
+// The function is called synchronously, so it must not log
+// with this logger.
+func (l *CustomPatternLogger) OnPatternError(fn func(error)) {
+	hook := l.getFormatter().(customPatternFormatter).onErr
+	hook.mu.Lock()
+	defer hook.mu.Unlock()
 
-// Warn prints the given values with log level WARN.
-func (l *CustomPatternLogger) Warn(v ...interface{}) {
-	l.print0(LevelWarn, v...)
+	hook.fn = fn
 }
 
-// Warnf formats and prints the given values with log level WARN.
-func (l *CustomPatternLogger) Warnf(format string, v ...interface{}) {
-	l.printf0(LevelWarn, format, v...)
+// ColorMode returns the color mode of this logger.
+func (l *CustomPatternLogger) ColorMode() ColorMode {
+	return l.getColorMode()
 }
 
-// Error prints the given values with log level ERR.
-func (l *CustomPatternLogger) Error(v ...interface{}) {
-	l.print0(LevelError, v...)
+// SetColorMode sets the color mode of this logger, which controls
+// whether the color function of the pattern writes color codes.
+// The color mode is described at ColoredLogger.
+// No colors are written with ColorModeNever, which is the default,
+// like for all other loggers of this package.
+func (l *CustomPatternLogger) SetColorMode(mode ColorMode) {
+	l.setColorMode(mode)
 }
 
-// Errorf formats and prints the given values with log level ERR.
-func (l *CustomPatternLogger) Errorf(format string, v ...interface{}) {
-	l.printf0(LevelError, format, v...)
+// Theme returns a copy of the color theme of this logger.
+func (l *CustomPatternLogger) Theme() ColorTheme {
+	return l.getTheme()
 }
 
-// Fatal prints the given values with log level FATAL.
-// IT DOES NOT TERMINATE THE APPLICATION.
-func (l *CustomPatternLogger) Fatal(v ...interface{}) {
-	l.print0(LevelFatal, v...)
+// SetTheme sets the color theme, that the color function of the
+// pattern uses.
+// The theme is copied, so later changes of the given theme
+// have no effect.
+func (l *CustomPatternLogger) SetTheme(theme ColorTheme) {
+	l.setTheme(theme)
 }
 
-// Fatalf formats and prints the given values with log level FATAL.
-// IT DOES NOT TERMINATE THE APPLICATION.
-func (l *CustomPatternLogger) Fatalf(format string, v ...interface{}) {
-	l.printf0(LevelFatal, format, v...)
-}
-
-// Level returns the current level of this logger.
-func (l *CustomPatternLogger) Level() LogLevel {
-	l.lvlMux.Lock()
-	defer l.lvlMux.Unlock()
-
-	return l.lvl
-}
-
-// SetLevel changes the log level of this logger.
-func (l *CustomPatternLogger) SetLevel(lvl LogLevel) {
-	l.lvlMux.Lock()
-	defer l.lvlMux.Unlock()
-
-	l.lvl = lvl
-}
-
-func (s *CustomPatternLogger) SetLevelString(level string) {
-	s.SetLevel(ToLogLevel(level))
-}
-
-// IsLevelEnabled returns true if and only if this logger would print
-// messages with the given log level.
-// False otherwise.
-func (l *CustomPatternLogger) IsLevelEnabled(lvl LogLevel) bool {
-	return lvl >= l.Level()
-}
-
-// clock returns the clock of this logger.
-func (l *CustomPatternLogger) clock() clock {
-	return l.clk
-}
-
-// SetClock sets a new clock for this logger.
-func (l *CustomPatternLogger) SetClock(clk clock) {
-	l.clockMux.Lock()
-	defer l.clockMux.Unlock()
-	l.clk = clk
-}
-
-// Out returns the writer of this logger.
-func (l *CustomPatternLogger) Out() io.Writer {
-	return l.out
-}
-
-// SetOut sets a new writer for this logger.
-func (l *CustomPatternLogger) SetOut(out io.Writer) {
-	l.outMux.Lock()
-	defer l.outMux.Unlock()
-	l.out = out
-}
-
-// =======================================================
-
-type customPatternLoggerTemplateData struct {
-	clk     clock
-	Level   string
-	Message string
-
-	initialized uint32
-	callerMux   sync.Mutex
-	pc          uintptr
-	file        string
-	line        int
-	function    *runtime.Func
-}
-
-func (l *customPatternLoggerTemplateData) Timestamp() string {
-	return l.Timestampf(TimeLayoutCustomPatternLogger)
-}
-
-func (l *customPatternLoggerTemplateData) Timestampf(layout string) string {
-	return l.clk.Now().Format(layout) // the formatted timestamp
-}
-
-func (l *customPatternLoggerTemplateData) File() string {
-	l.initCallerInfo()
-	return l.Filef("short")
-}
-
-func (l *customPatternLoggerTemplateData) Filef(mode string) string {
-	l.initCallerInfo()
-	if mode == "short" {
-		name := l.file
-		return filepath.Base(name)
-	}
-	return l.file // calling file
-}
-
-func (l *customPatternLoggerTemplateData) Line() int {
-	l.initCallerInfo()
-	return l.line // calling line number
-}
-
-func (l *customPatternLoggerTemplateData) Function() string {
-	l.initCallerInfo()
-	return l.Functionf("package")
-}
-
-func (l *customPatternLoggerTemplateData) Functionf(mode string) string {
-	l.initCallerInfo()
-	if mode == "short" {
-		name := l.function.Name()
-		return name[strings.LastIndex(name, ".")+1:]
-	} else if mode == "package" {
-		return filepath.Base(l.function.Name())
-	} else {
-		return l.function.Name() // calling package
-	}
-}
-
-func (l *customPatternLoggerTemplateData) initCallerInfo() {
-	if atomic.LoadUint32(&l.initialized) == 1 {
-		return
-	}
-
-	l.callerMux.Lock()
-	defer l.callerMux.Unlock()
-
-	if l.initialized == 0 {
-		var ok bool
-		l.pc, l.file, l.line, ok = runtime.Caller(18)
-		l.function = runtime.FuncForPC(l.pc)
-
-		if ok {
-			atomic.AddUint32(&l.initialized, 1)
-		}
-	}
+// ColorsEnabled returns whether the color function of the pattern
+// currently writes color codes.
+func (l *CustomPatternLogger) ColorsEnabled() bool {
+	return l.colorsEnabled()
 }
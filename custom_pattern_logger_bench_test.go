@@ -5,12 +5,7 @@ import (
 )
 
 func BenchmarkCustomPatternLogger_Printf(b *testing.B) {
-	logger := &CustomPatternLogger{
-		clk:     &mockClock{},
-		lvl:     LevelVerbose,
-		out:     &MockWriter{},
-		pattern: "{{.Timestamp}} [{{.Level}}] - {{.Message}}\n",
-	}
+	logger := newTestCustomPatternLogger("{{.Timestamp}} [{{.Level}}] - {{.Message}}\n", LevelVerbose, &MockWriter{})
 	b.ReportAllocs()
 	b.ResetTimer()
 	b.RunParallel(func(pb *testing.PB) {
@@ -20,12 +15,7 @@ func BenchmarkCustomPatternLogger_Printf(b *testing.B) {
 	})
 }
 func BenchmarkCustomPatternLogger_Printf_Stack_Ops(b *testing.B) {
-	logger := &CustomPatternLogger{
-		clk:     &mockClock{},
-		lvl:     LevelVerbose,
-		out:     &MockWriter{},
-		pattern: "{{.Timestamp}} {{.File}}:{{.Line}} {{.Function}} [{{.Level}}] - {{.Message}}\n",
-	}
+	logger := newTestCustomPatternLogger("{{.Timestamp}} {{.File}}:{{.Line}} {{.Function}} [{{.Level}}] - {{.Message}}\n", LevelVerbose, &MockWriter{})
 	b.ReportAllocs()
 	b.ResetTimer()
 	b.RunParallel(func(pb *testing.PB) {
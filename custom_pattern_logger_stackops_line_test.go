@@ -28,12 +28,12 @@ func TestCustomPatternLogger_Stackops_Line(t *testing.T) {
 		}
 	}
 
-	logger := &CustomPatternLogger{
-		clk:     &mockClock{},
-		lvl:     LevelVerbose,
-		out:     buf,
-		pattern: `{{.Line}}`,
-	}
+	logger := newTestCustomPatternLogger(
+		`{{.Line}}`,
+		LevelVerbose,
+		buf,
+	)
+	// the logger must be created in 6 lines to keep the line numbers below
 
 	// actual test flow
 	logger.Verbose("")        // this is line 39
@@ -12,12 +12,7 @@ func TestCustomPatternLogger_Stackops_File(t *testing.T) {
 
 	buf := &bytes.Buffer{}
 
-	logger := &CustomPatternLogger{
-		clk:     &mockClock{},
-		lvl:     LevelVerbose,
-		out:     buf,
-		pattern: `{{.File}} {{.Filef "short"}}`,
-	}
+	logger := newTestCustomPatternLogger(`{{.File}} {{.Filef "short"}}`, LevelVerbose, buf)
 
 	check := func() {
 		defer buf.Reset()
@@ -70,12 +65,7 @@ func TestCustomPatternLogger_Stackops_Function(t *testing.T) {
 
 	buf := &bytes.Buffer{}
 
-	logger := &CustomPatternLogger{
-		clk:     &mockClock{},
-		lvl:     LevelVerbose,
-		out:     buf,
-		pattern: `{{.Function}} {{.Functionf "short"}} {{.Functionf "full"}} {{.Functionf "package"}}`,
-	}
+	logger := newTestCustomPatternLogger(`{{.Function}} {{.Functionf "short"}} {{.Functionf "full"}} {{.Functionf "package"}}`, LevelVerbose, buf)
 
 	check := func() {
 		defer buf.Reset()
@@ -2,7 +2,11 @@ package abc
 
 import (
 	"bytes"
+	"errors"
+	"strings"
+	"sync"
 	"testing"
+	"text/template"
 
 	"github.com/sergi/go-diff/diffmatchpatch"
 	"github.com/stretchr/testify/assert"
@@ -11,12 +15,7 @@ import (
 func TestCustomPatternLogger_Printf(t *testing.T) {
 	buf := &bytes.Buffer{}
 
-	logger := &CustomPatternLogger{
-		clk:     &mockClock{},
-		lvl:     LevelDebug,
-		out:     buf,
-		pattern: "{{.Timestamp}} [{{.Level}}] - {{.Message}}\n",
-	}
+	logger := newTestCustomPatternLogger("{{.Timestamp}} [{{.Level}}] - {{.Message}}\n", LevelDebug, buf)
 
 	type args struct {
 		lvl    LogLevel
@@ -81,12 +80,7 @@ func TestCustomPatternLogger_Printf(t *testing.T) {
 func TestCustomPatternLogger_Print(t *testing.T) {
 	buf := &bytes.Buffer{}
 
-	logger := &CustomPatternLogger{
-		clk:     &mockClock{},
-		lvl:     LevelDebug,
-		out:     buf,
-		pattern: "{{.Timestamp}} [{{.Level}}] - {{.Message}}\n",
-	}
+	logger := newTestCustomPatternLogger("{{.Timestamp}} [{{.Level}}] - {{.Message}}\n", LevelDebug, buf)
 
 	type args struct {
 		lvl LogLevel
@@ -187,12 +181,7 @@ func TestCustomPatternLogger_All_Outputs(t *testing.T) {
 
 	buf := &bytes.Buffer{}
 
-	logger := &CustomPatternLogger{
-		clk:     &mockClock{},
-		lvl:     LevelVerbose,
-		out:     buf,
-		pattern: "{{.Timestamp}} [{{.Level}}] - {{.Message}}\n",
-	}
+	logger := newTestCustomPatternLogger("{{.Timestamp}} [{{.Level}}] - {{.Message}}\n", LevelVerbose, buf)
 
 	check := func() {
 		defer func() {
@@ -249,12 +238,7 @@ func TestCustomPatternLogger_SetOut(t *testing.T) {
 	buf1 := &bytes.Buffer{}
 	buf2 := &bytes.Buffer{}
 
-	logger := &CustomPatternLogger{
-		clk:     &mockClock{},
-		lvl:     LevelVerbose,
-		out:     buf1,
-		pattern: "{{.Timestamp}} [{{.Level}}] - {{.Message}}\n",
-	}
+	logger := newTestCustomPatternLogger("{{.Timestamp}} [{{.Level}}] - {{.Message}}\n", LevelVerbose, buf1)
 
 	logger.Info("foo")
 	assert.Equal("0001-01-01 00:00:00.000 [INFO] - foo\n", buf1.String(), "buf1 did receive wrong output.")
@@ -284,12 +268,7 @@ func TestCustomPatternLogger_SetLevel(t *testing.T) {
 
 	buf := &bytes.Buffer{}
 
-	logger := &CustomPatternLogger{
-		clk:     &mockClock{},
-		lvl:     LevelVerbose,
-		out:     buf,
-		pattern: "{{.Timestamp}} [{{.Level}}] - {{.Message}}\n",
-	}
+	logger := newTestCustomPatternLogger("{{.Timestamp}} [{{.Level}}] - {{.Message}}\n", LevelVerbose, buf)
 
 	logger.Verbose("foo")
 	assert.Equal("0001-01-01 00:00:00.000 [DEBG] - foo\n", buf.String(), "buf did receive wrong output.")
@@ -300,3 +279,190 @@ func TestCustomPatternLogger_SetLevel(t *testing.T) {
 	logger.Verbose("foo")
 	assert.Equal("", buf.String(), "buf did receive output.")
 }
+
+func TestCustomPatternLogger_With(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	logger := newTestCustomPatternLogger("[{{.Level}}] {{.Message}} ({{.Fields}})|{{range .Fields}}{{.Key}}:{{.Value}};{{end}}\n", LevelVerbose, buf)
+
+	logger.With("request", 17, "user", "John Doe").Info("abc")
+	assert.Equal("[INFO] abc (request=17 user=\"John Doe\")|request:17;user:John Doe;\n", buf.String(), "buf did receive wrong output.")
+
+	buf.Reset()
+
+	logger.Info("abc")
+	assert.Equal("[INFO] abc ()|\n", buf.String(), "buf did receive wrong output.")
+}
+
+func TestCustomPatternLogger_SetPattern(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	logger := newTestCustomPatternLogger("[{{.Level}}] {{.Message}}\n", LevelInfo, buf)
+	derived := logger.With()
+
+	assert.NoError(logger.SetPattern("{{.Level | lower}}: {{.Message}}\n"))
+	assert.Equal("{{.Level | lower}}: {{.Message}}\n", logger.Pattern())
+	logger.Info("abc")
+	derived.Info("def")
+	assert.Equal("info: abc\n[INFO] def\n", buf.String(), "The derived logger must keep its pattern")
+
+	buf.Reset()
+
+	assert.EqualError(logger.SetPattern("{{.Foo}}"), "pattern:1:3: unknown verb .Foo")
+	logger.Info("abc")
+	assert.Equal("info: abc\n", buf.String(), "The pattern must be kept if the new pattern is invalid")
+}
+
+func TestCustomPatternLogger_Seq(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	logger := newTestCustomPatternLogger("{{.Seq}} {{.Message}}\n", LevelInfo, buf)
+	derived := logger.With("key", "value")
+
+	logger.Info("a")
+	derived.Info("b")
+	assert.NoError(logger.SetPattern("{{.Seq}}: {{.Message}}\n"))
+	logger.Info("c")
+	derived.Info("d")
+	assert.NoError(logger.SetPattern("{{if .Message}}{{.Seq}}{{end}} {{.Message}}\n"))
+	logger.Info("e")
+	assert.Equal("1 a\n2 b\n3: c\n4 d\n5 e\n", buf.String(), "Seq must be shared with derived loggers and kept by SetPattern")
+}
+
+func TestCustomPatternLogger_SetPattern_Conversion(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	logger := must(NewConversionPatternLogger("%p %m%n")).(*CustomPatternLogger)
+	logger.SetOut(buf)
+
+	assert.NoError(logger.SetPattern("%-5p|%m%n"))
+	assert.EqualError(logger.SetPattern("{{.Message}}%q"), `pattern:1:14: unknown conversion word "q"`, "The syntax of the logger must be kept")
+	logger.Info("abc")
+	assert.Equal("INFO |abc\n", buf.String())
+}
+
+func TestCustomPatternLogger_RecordsCaller(t *testing.T) {
+	assert := assert.New(t)
+
+	for pattern, expected := range map[string]bool{
+		"{{.Timestamp}} [{{.Level}}] - {{.Message}}": false,
+		"{{range .Fields}}{{.Key}}{{end}}":           false,
+		"{{.File}}":                                  true,
+		"{{.Filef \"full\"}}":                        true,
+		"{{.Line | pad 5}}":                          true,
+		"{{.Function}}":                              true,
+		"{{.Functionf \"short\"}}":                   true,
+	} {
+		logger := must(NewCustomPatternLogger(pattern)).(*CustomPatternLogger)
+		assert.Equal(expected, logger.recordsCaller(), pattern)
+	}
+
+	for pattern, expected := range map[string]bool{
+		"%d [%p] %c - %m%n": false,
+		"%F":                true,
+		"%-20L":             true,
+		"%M":                true,
+		"%l":                true,
+	} {
+		logger := must(NewConversionPatternLogger(pattern)).(*CustomPatternLogger)
+		assert.Equal(expected, logger.recordsCaller(), pattern)
+	}
+
+	logger := newTestCustomPatternLogger("{{.Message}}", LevelInfo, &bytes.Buffer{})
+	assert.NoError(logger.SetPattern("{{.Line}}"))
+	assert.True(logger.recordsCaller(), "SetPattern must record the caller for the new pattern")
+	assert.NoError(logger.SetPattern("{{.Message}}"))
+	assert.False(logger.recordsCaller(), "SetPattern must not record the caller for the new pattern")
+}
+
+func TestCustomPatternLogger_SetPattern_Concurrent(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &syncBuffer{}
+
+	logger := newTestCustomPatternLogger("a{{.Message}}a\n", LevelInfo, buf)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				logger.Info("-")
+			}
+		}()
+	}
+	for j := 0; j < 100; j++ {
+		assert.NoError(logger.SetPattern("b{{.Message}}b\n"))
+		assert.NoError(logger.SetPattern("a{{.Message}}a\n"))
+	}
+	wg.Wait()
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if line != "a-a" && line != "b-b" {
+			assert.Fail("Every message must be printed with exactly one pattern", line)
+		}
+	}
+}
+
+func TestCustomPatternLogger_OnPatternError(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	logger := must(NewCustomPatternLoggerWithFuncs("{{.Message | check}}|{{explode}}\n", template.FuncMap{
+		"check": func(s string) (string, error) {
+			if s == "" {
+				return "", errors.New("empty message")
+			}
+			return s, nil
+		},
+		"explode": func() string { panic("boom") },
+	})).(*CustomPatternLogger)
+	logger.SetOut(buf)
+
+	var errs []error
+	logger.OnPatternError(func(err error) {
+		errs = append(errs, err)
+	})
+
+	logger.With().Info("")
+	assert.Equal("%!check(empty message)|%!explode(panic: boom)\n", buf.String(), "The message must be printed with the errors")
+	if assert.Len(errs, 1, "Only the first error of a message must be reported") {
+		assert.EqualError(errs[0], "calling check: empty message")
+	}
+}
+
+func TestCustomPatternLogger_Color(t *testing.T) {
+	assert := assert.New(t)
+
+	t.Setenv("FORCE_COLOR", "")
+	t.Setenv("NO_COLOR", "")
+
+	buf := &bytes.Buffer{}
+	logger := newTestCustomPatternLogger("[{{color .Level}}] {{.Message}}\n", LevelInfo, buf)
+	assert.Equal(ColorModeNever, logger.ColorMode())
+
+	logger.Info("abc")
+	assert.Equal("[INFO] abc\n", buf.String(), "No color codes must be written by default")
+
+	buf.Reset()
+	logger.SetColorMode(ColorModeAlways)
+	logger.Info("abc")
+	logger.With("request", 17).Error("abc")
+	assert.Equal("["+string(ColorGreen)+"INFO"+string(ColorReset)+"] abc\n["+string(ColorRed)+"ERR "+string(ColorReset)+"] abc\n", buf.String())
+
+	buf.Reset()
+	t.Setenv("FORCE_COLOR", "1")
+	logger.SetColorMode(ColorModeAuto)
+	logger.Info("abc")
+	assert.Equal("["+string(ColorGreen)+"INFO"+string(ColorReset)+"] abc\n", buf.String())
+}
@@ -8,7 +8,15 @@ import (
 )
 
 func main() {
-	file, _ := os.Create("my.file")
+	// rotates my.log when it exceeds 10MB, keeping my.log.1 to my.log.3
+	file, err := abc.NewRotatingFile("my.log", abc.RotatingFileOptions{
+		MaxSize:    10 * 1024 * 1024,
+		MaxBackups: 3,
+	})
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
 
 	logger := abc.NewSimpleLogger()
 	logger.SetOut(io.MultiWriter(file, os.Stdout)) // writes to file and stdout
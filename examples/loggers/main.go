@@ -3,10 +3,15 @@ package main
 import "github.com/TimSatke/abc"
 
 func main() {
+	patternLogger, err := abc.NewCustomPatternLogger(`{{.Timestamp}} {{.File}}:{{.Line}} {{.Function}} [{{.Level}}] - {{.Message}}` + "\n")
+	if err != nil {
+		panic(err)
+	}
+
 	loggers := []abc.Logger{
 		abc.NewSimpleLogger(),
 		abc.NewNamedLogger("MyLogger"),
-		abc.must(abc.NewCustomPatternLogger(`{{.Timestamp}} {{.File}}:{{.Line}} {{.Function}} [{{.Level}}] - {{.Message}}` + "\n")),
+		patternLogger,
 		abc.NewColoredLogger(abc.NewSimpleLogger()),
 	}
 
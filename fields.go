@@ -0,0 +1,102 @@
+package abc
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// FieldValueMissing is the value that is used for a key
+	// that was passed to With without a value.
+	FieldValueMissing = "!MISSING"
+)
+
+// Field is a key/value pair that a logger attaches to
+// every message it prints.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// String returns the field in the form key=value.
+// The value is quoted if it is empty or contains
+// whitespace, quotes, equal signs or control characters.
+func (f Field) String() string {
+	return f.Key + "=" + quoteFieldValue(fmt.Sprint(f.Value))
+}
+
+// Fields is an ordered list of fields.
+type Fields []Field
+
+// String returns the fields in the form
+//
+//	key=value key2="value with spaces"
+//
+// or an empty string, if there are no fields.
+func (f Fields) String() string {
+	if len(f) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for i, field := range f {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(field.String())
+	}
+	return b.String()
+}
+
+// with returns a new list of fields, containing all fields
+// of f, followed by the given key/value pairs.
+// Keys that are not strings are converted with fmt.Sprint.
+// A trailing key without a value gets the value FieldValueMissing.
+// f is never modified, so that loggers derived from the same
+// logger don't share their fields.
+func (f Fields) with(keyvals ...interface{}) Fields {
+	result := make(Fields, len(f), len(f)+(len(keyvals)+1)/2)
+	copy(result, f)
+
+	for i := 0; i < len(keyvals); i += 2 {
+		key, ok := keyvals[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyvals[i])
+		}
+
+		var value interface{} = FieldValueMissing
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+
+		result = append(result, Field{key, value})
+	}
+	return result
+}
+
+// writeSuffix writes every field with a leading space
+// to the given buffer.
+// It is used by loggers that print the fields after
+// the message.
+func (f Fields) writeSuffix(buf *bytes.Buffer) {
+	for _, field := range f {
+		buf.WriteByte(' ')
+		buf.WriteString(field.String())
+	}
+}
+
+func quoteFieldValue(s string) string {
+	if s == "" {
+		return `""`
+	}
+
+	for _, r := range s {
+		if unicode.IsSpace(r) || r == '"' || r == '=' || !unicode.IsPrint(r) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+package abc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFields_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   Fields
+		expected string
+	}{
+		{
+			"No fields",
+			nil,
+			"",
+		},
+		{
+			"Single field",
+			Fields{{"key", "value"}},
+			"key=value",
+		},
+		{
+			"Multiple fields",
+			Fields{{"a", 1}, {"b", true}, {"c", -0.5}},
+			"a=1 b=true c=-0.5",
+		},
+		{
+			"Quoted values",
+			Fields{{"space", "a b"}, {"quote", `a"b`}, {"equals", "a=b"}, {"newline", "a\nb"}, {"empty", ""}},
+			`space="a b" quote="a\"b" equals="a=b" newline="a\nb" empty=""`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.fields.String())
+		})
+	}
+}
+
+func TestFields_with(t *testing.T) {
+	assert := assert.New(t)
+
+	base := Fields{{"a", 1}}
+
+	derived := base.with("b", 2, 3, "c", "d")
+	assert.Equal(Fields{{"a", 1}, {"b", 2}, {"3", "c"}, {"d", FieldValueMissing}}, derived)
+	assert.Equal(Fields{{"a", 1}}, base, "with must not modify the original fields")
+
+	// derived fields must not share their backing array
+	one := base.with("x", 1)
+	two := base.with("y", 2)
+	assert.Equal(Fields{{"a", 1}, {"x", 1}}, one)
+	assert.Equal(Fields{{"a", 1}, {"y", 2}}, two)
+}
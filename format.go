@@ -0,0 +1,79 @@
+package abc
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxPooledBufferSize is the capacity up to which buffers are
+// returned to the pool, so that a single huge message doesn't
+// keep its memory forever.
+const maxPooledBufferSize = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return &bytes.Buffer{}
+	},
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer resets the given buffer and returns it to the pool.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+var recordPool = sync.Pool{
+	New: func() interface{} {
+		return &Record{}
+	},
+}
+
+// timestampCache formats timestamps with a layout, whose smallest
+// unit is at most a millisecond.
+// It caches the formatted timestamp of the last millisecond, so that
+// the timestamp is only formatted once per millisecond.
+type timestampCache struct {
+	layout string
+	last   atomic.Pointer[cachedTimestamp]
+}
+
+// cachedTimestamp is an immutable formatted timestamp.
+type cachedTimestamp struct {
+	milli int64
+	loc   *time.Location
+	text  []byte
+}
+
+// write writes the given time, formatted with the layout of the
+// cache, to the given buffer.
+func (c *timestampCache) write(buf *bytes.Buffer, t time.Time) {
+	milli, loc := t.UnixMilli(), t.Location()
+	if last := c.last.Load(); last != nil && last.milli == milli && last.loc == loc {
+		buf.Write(last.text)
+		return
+	}
+
+	text := t.AppendFormat(nil, c.layout)
+	c.last.Store(&cachedTimestamp{milli: milli, loc: loc, text: text})
+	buf.Write(text)
+}
+
+// writePaddedLevel writes the given level, padded with
+// spaces to at least 4 characters.
+func writePaddedLevel(buf *bytes.Buffer, lvl LogLevel) {
+	s := lvl.String()
+	buf.WriteString(s)
+	for i := len(s); i < 4; i++ {
+		buf.WriteByte(' ')
+	}
+}
@@ -0,0 +1,54 @@
+package abc
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimestampCache(t *testing.T) {
+	assert := assert.New(t)
+
+	cache := &timestampCache{layout: "15:04:05.000 MST"}
+	write := func(t time.Time) string {
+		buf := &bytes.Buffer{}
+		cache.write(buf, t)
+		return buf.String()
+	}
+
+	base := time.Date(2018, 11, 24, 15, 26, 44, 453000000, time.UTC)
+	assert.Equal("15:26:44.453 UTC", write(base))
+	assert.Equal("15:26:44.453 UTC", write(base.Add(999*time.Microsecond)), "The cached timestamp must be used within the same millisecond")
+	assert.Equal("15:26:44.454 UTC", write(base.Add(time.Millisecond)))
+	assert.Equal("15:26:44.453 UTC", write(base), "Earlier timestamps must be formatted again")
+
+	cet := time.FixedZone("CET", 3600)
+	assert.Equal("16:26:44.453 CET", write(base.In(cet)), "The cache must respect the location")
+}
+
+func TestFormatters_Allocs(t *testing.T) {
+	rec := &Record{
+		Time:    time.Now(),
+		Level:   LevelInfo,
+		Message: "Hello World!",
+		Name:    "MyLogger",
+	}
+	formatters := map[string]Formatter{
+		"simpleFormatter": simpleFormatter{},
+		"namedFormatter":  namedFormatter{},
+	}
+	for name, formatter := range formatters {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			allocs := testing.AllocsPerRun(100, func() {
+				buf.Reset()
+				_ = formatter.Format(buf, rec)
+			})
+			if allocs != 0 {
+				t.Errorf("Formatting must not allocate, but allocated %v times", allocs)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package abc
+
+// FormatterLogger is a logger that formats its messages with
+// a custom Formatter and writes them to its output writer.
+// It can be used to implement custom output formats without
+// implementing the whole abc.Logger interface.
+//
+//	logger := abc.NewFormatterLogger(abc.FormatterFunc(func(buf *bytes.Buffer, rec *abc.Record) error {
+//		_, err := fmt.Fprintf(buf, "%v %v\n", rec.Level, rec.Message)
+//		return err
+//	}))
+//
+// FormatterLoggers are safe for concurrent use, if their
+// formatter is.
+type FormatterLogger struct {
+	writerCore
+}
+
+// With returns a new FormatterLogger that passes the given key/value
+// pairs to its formatter, in addition to the fields of this logger.
+// The new logger starts with the level, clock, writer and formatter of this logger.
+func (l *FormatterLogger) With(keyvals ...interface{}) Logger {
+	derived := &FormatterLogger{}
+	l.derive(&derived.writerCore, keyvals...)
+	return derived
+}
@@ -0,0 +1,28 @@
+package abc
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatterLogger(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	l := NewFormatterLogger(FormatterFunc(func(buf *bytes.Buffer, rec *Record) error {
+		_, err := fmt.Fprintf(buf, "%v|%v|%v\n", rec.Level, rec.Message, rec.Fields)
+		return err
+	}))
+	l.SetOut(buf)
+	l.SetLevel(LevelDebug)
+
+	l.Verbose("suppressed")
+	l.Debug("abc")
+	l.(FieldLogger).With("request", 17).Error("abc")
+
+	assert.Equal("DEBG|abc|\nERR|abc|request=17\n", buf.String())
+}
@@ -1,7 +1,14 @@
 module github.com/TimSatke/abc
 
+go 1.21
+
 require (
 	github.com/sergi/go-diff v1.0.0
 	github.com/stretchr/testify v1.2.2
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 	gitlab.com/TimSatke/abc v0.0.0-20190410092415-79a9c3751cfb // indirect
 )
@@ -0,0 +1,43 @@
+package abc
+
+import "io"
+
+// HandlerLogger is a logger that passes a Record for every message
+// that passes the level check to its Handler.
+// It can be used to send log messages anywhere, without
+// implementing the whole abc.Logger interface.
+//
+// If the handler is a LevelHandler, messages are only passed
+// to it if both the logger and the handler enable their level.
+//
+// HandlerLoggers are safe for concurrent use, if their
+// handler is.
+type HandlerLogger struct {
+	core
+}
+
+// With returns a new HandlerLogger that passes the given key/value
+// pairs to its handler, in addition to the fields of this logger.
+// The new logger starts with the level, clock and handler of this logger.
+func (l *HandlerLogger) With(keyvals ...interface{}) Logger {
+	derived := &HandlerLogger{}
+	l.derive(&derived.core, keyvals...)
+	derived.setHandler(l.handler)
+	return derived
+}
+
+// Sync syncs the handler of this logger, if it implements Syncer.
+func (l *HandlerLogger) Sync() error {
+	if s, ok := l.handler.(Syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Close closes the handler of this logger, if it implements io.Closer.
+func (l *HandlerLogger) Close() error {
+	if c, ok := l.handler.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package abc
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerLogger(t *testing.T) {
+	assert := assert.New(t)
+
+	var records []Record
+	l := NewHandlerLogger(HandlerFunc(func(rec *Record) error {
+		records = append(records, *rec)
+		return nil
+	}))
+	l.(*HandlerLogger).SetClock(&mockClock{})
+
+	l.Debug("suppressed")
+	l.(FieldLogger).With("request", 17).Infof("fmt: %v", "abc") // this is line 21
+	l.Warn("abc")
+
+	assert.Len(records, 2)
+
+	rec := records[0]
+	assert.Equal(LevelInfo, rec.Level)
+	assert.Equal("fmt: abc", rec.Message)
+	assert.Equal(Fields{{"request", 17}}, rec.Fields)
+	assert.True(rec.Time.IsZero(), "Time must be taken from the clock of the logger")
+	assert.Equal("handler_logger_test.go", filepath.Base(rec.Caller().File))
+	assert.Equal(21, rec.Caller().Line)
+
+	assert.Equal(LevelWarn, records[1].Level)
+	assert.Empty(records[1].Fields, "Fields of derived logger must not be added to the original logger")
+}
+
+// closingHandler is a handler that counts calls of Sync and Close.
+type closingHandler struct {
+	HandlerFunc
+	syncs, closes int
+}
+
+func (h *closingHandler) Sync() error {
+	h.syncs++
+	return nil
+}
+
+func (h *closingHandler) Close() error {
+	h.closes++
+	return nil
+}
+
+func TestHandlerLogger_SyncClose(t *testing.T) {
+	assert := assert.New(t)
+
+	h := &closingHandler{HandlerFunc: func(*Record) error { return nil }}
+	l := NewHandlerLogger(h).(*HandlerLogger)
+	assert.NoError(l.Sync())
+	assert.NoError(l.With("key", "value").(*HandlerLogger).Close())
+	assert.Equal(1, h.syncs)
+	assert.Equal(1, h.closes)
+
+	l = NewHandlerLogger(HandlerFunc(func(*Record) error { return nil })).(*HandlerLogger)
+	assert.NoError(l.Sync())
+	assert.NoError(l.Close())
+}
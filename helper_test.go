@@ -0,0 +1,78 @@
+package abc
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// The following functions create loggers for tests, that use
+// the mock clock and the given level and writer.
+
+func newTestSimpleLogger(lvl LogLevel, out io.Writer) *SimpleLogger {
+	logger := NewSimpleLogger().(*SimpleLogger)
+	logger.SetClock(&mockClock{})
+	logger.SetLevel(lvl)
+	logger.SetOut(out)
+	return logger
+}
+
+func newTestNamedLogger(name string, lvl LogLevel, out io.Writer) *NamedLogger {
+	logger := NewNamedLogger(name).(*NamedLogger)
+	logger.SetClock(&mockClock{})
+	logger.SetLevel(lvl)
+	logger.SetOut(out)
+	return logger
+}
+
+func newTestCustomPatternLogger(pattern string, lvl LogLevel, out io.Writer) *CustomPatternLogger {
+	logger := must(NewCustomPatternLogger(pattern)).(*CustomPatternLogger)
+	logger.SetClock(&mockClock{})
+	logger.SetLevel(lvl)
+	logger.SetOut(out)
+	return logger
+}
+
+func newTestJSONLogger(name string, lvl LogLevel, out io.Writer) *JSONLogger {
+	logger := NewJSONLogger().(*JSONLogger)
+	logger.SetName(name)
+	logger.SetClock(&mockClock{})
+	logger.SetLevel(lvl)
+	logger.SetOut(out)
+	return logger
+}
+
+// newTestColoredLogger wraps the given logger in a ColoredLogger,
+// that always writes color codes.
+func newTestColoredLogger(wrapped WriterLogger) *ColoredLogger {
+	logger := NewColoredLogger(wrapped).(*ColoredLogger)
+	logger.SetColorMode(ColorModeAlways)
+	return logger
+}
+
+func newTestLogfmtLogger(name string, lvl LogLevel, out io.Writer) *LogfmtLogger {
+	logger := NewLogfmtLogger().(*LogfmtLogger)
+	logger.SetName(name)
+	logger.SetClock(&mockClock{})
+	logger.SetLevel(lvl)
+	logger.SetOut(out)
+	return logger
+}
+
+// syncBuffer is a bytes.Buffer, that is safe for concurrent writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,239 @@
+package abc
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+// hierarchyMux guards the nodes of all hierarchies of NamedLoggers.
+var hierarchyMux sync.Mutex
+
+// loggerNode is the node of a NamedLogger in a hierarchy of
+// NamedLoggers, that were created with Child.
+// Changes of the level and the writer are pushed down to all
+// children, that inherit them, so that the level check of a
+// logger never has to look at its parents.
+type loggerNode struct {
+	logger   *NamedLogger
+	parent   *loggerNode
+	children map[string]*loggerNode
+
+	// levelSet and outSet are whether the level and the writer
+	// were set on the logger, instead of being inherited from
+	// the parent.
+	levelSet bool
+	outSet   bool
+
+	// registered is whether the node belongs to the registry of
+	// GetLogger, whose level rules apply to it.
+	registered bool
+	// levelRuled is whether the level was set by a level rule of
+	// SetLevels. unruledSet and unruledLvl are the level of the
+	// node before, which is restored when no rule matches anymore.
+	levelRuled bool
+	unruledSet bool
+	unruledLvl LogLevel
+}
+
+// nodeLocked returns the node of the logger, which is created
+// if the logger is not part of a hierarchy yet.
+// hierarchyMux must be held.
+func (l *NamedLogger) nodeLocked() *loggerNode {
+	if l.node == nil {
+		l.node = &loggerNode{logger: l}
+	}
+	return l.node
+}
+
+// child returns the child node with the given name part, which
+// is created if it doesn't exist yet.
+// hierarchyMux must be held.
+func (n *loggerNode) child(part string) *loggerNode {
+	if child, ok := n.children[part]; ok {
+		return child
+	}
+
+	logger := &NamedLogger{}
+	n.logger.derive(&logger.writerCore)
+	logger.setName(joinLoggerName(n.logger.getName(), part))
+	child := &loggerNode{logger: logger, parent: n, registered: n.registered}
+	logger.node = child
+	if child.registered {
+		child.applyLevelRules()
+	}
+
+	if n.children == nil {
+		n.children = make(map[string]*loggerNode)
+	}
+	n.children[part] = child
+	return child
+}
+
+// propagateLevel sets the level of the node and all children,
+// that inherit their level.
+func (n *loggerNode) propagateLevel(lvl LogLevel) {
+	n.logger.core.SetLevel(lvl)
+	for _, child := range n.children {
+		if !child.levelSet {
+			child.propagateLevel(lvl)
+		}
+	}
+}
+
+// propagateOut sets the writer of the node and all children,
+// that inherit their writer.
+func (n *loggerNode) propagateOut(out io.Writer) {
+	n.logger.writerCore.SetOut(out)
+	for _, child := range n.children {
+		if !child.outSet {
+			child.propagateOut(out)
+		}
+	}
+}
+
+// walk calls the given function with the node and all nodes below it.
+func (n *loggerNode) walk(fn func(*loggerNode)) {
+	fn(n)
+	for _, child := range n.children {
+		child.walk(fn)
+	}
+}
+
+// joinLoggerName returns the name of the child with the given
+// name part of a logger with the given name.
+func joinLoggerName(parent, part string) string {
+	if parent == "" {
+		return part
+	}
+	return parent + "." + part
+}
+
+// Child returns the NamedLogger below this logger in the hierarchy
+// of named loggers, whose name is the name of this logger and the
+// given name, separated by a dot.
+// The given name may contain dots itself, e.g.
+//
+//	logger := abc.NewNamedLogger("app").(*abc.NamedLogger)
+//	pool := logger.Child("db.pool") // app.db.pool
+//
+// creates the loggers app.db and app.db.pool.
+// Every call with the same name returns the same logger.
+//
+// A child inherits the level and the writer of its parent, until they
+// are set on the child. Later changes of the level or the writer of
+// the parent are applied to all children, that inherit them.
+// The clock, the format and the fields are copied from the parent
+// when the child is created.
+//
+// Loggers derived with With are not part of the hierarchy and don't
+// follow later changes of it, but they can create their own children.
+func (l *NamedLogger) Child(name string) *NamedLogger {
+	hierarchyMux.Lock()
+	defer hierarchyMux.Unlock()
+
+	node := l.nodeLocked()
+	for _, part := range strings.Split(name, ".") {
+		if part != "" {
+			node = node.child(part)
+		}
+	}
+	return node.logger
+}
+
+// SetLevel changes the log level of this logger and of all loggers
+// below it in the hierarchy, that inherit their level.
+// The level of this logger is no longer inherited from its parent.
+func (l *NamedLogger) SetLevel(lvl LogLevel) {
+	hierarchyMux.Lock()
+	defer hierarchyMux.Unlock()
+
+	node := l.nodeLocked()
+	node.levelSet, node.levelRuled = true, false
+	node.propagateLevel(lvl)
+}
+
+// SetLevelString changes to log level of this logger like SetLevel.
+// See ToLogLevel for the accepted values.
+func (l *NamedLogger) SetLevelString(level string) {
+	l.SetLevel(ToLogLevel(level))
+}
+
+// SetSubtreeLevel changes the log level of this logger and of all
+// loggers below it in the hierarchy, including the ones that had
+// their own level. All loggers below this logger inherit their
+// level again.
+func (l *NamedLogger) SetSubtreeLevel(lvl LogLevel) {
+	hierarchyMux.Lock()
+	defer hierarchyMux.Unlock()
+
+	node := l.nodeLocked()
+	node.walk(func(n *loggerNode) {
+		n.levelSet, n.levelRuled = false, false
+	})
+	node.levelSet = true
+	node.propagateLevel(lvl)
+}
+
+// InheritLevel makes this logger inherit the level of its parent
+// again, after a level was set with SetLevel.
+// It has no effect on loggers without parent.
+func (l *NamedLogger) InheritLevel() {
+	hierarchyMux.Lock()
+	defer hierarchyMux.Unlock()
+
+	node := l.nodeLocked()
+	if node.parent == nil {
+		return
+	}
+	node.levelSet, node.levelRuled = false, false
+	node.propagateLevel(node.parent.logger.Level())
+}
+
+// InheritsLevel returns whether this logger inherits the level
+// of its parent.
+func (l *NamedLogger) InheritsLevel() bool {
+	hierarchyMux.Lock()
+	defer hierarchyMux.Unlock()
+
+	return l.node != nil && l.node.parent != nil && !l.node.levelSet
+}
+
+// SetOut sets a new writer for this logger and for all loggers
+// below it in the hierarchy, that inherit their writer.
+// The writer of this logger is no longer inherited from its parent.
+func (l *NamedLogger) SetOut(out io.Writer) {
+	hierarchyMux.Lock()
+	defer hierarchyMux.Unlock()
+
+	node := l.nodeLocked()
+	node.outSet = true
+	node.propagateOut(out)
+}
+
+// InheritOut makes this logger inherit the writer of its parent
+// again, after a writer was set with SetOut.
+// It has no effect on loggers without parent.
+func (l *NamedLogger) InheritOut() {
+	hierarchyMux.Lock()
+	defer hierarchyMux.Unlock()
+
+	node := l.nodeLocked()
+	if node.parent == nil {
+		return
+	}
+	node.outSet = false
+	node.propagateOut(node.parent.logger.Out())
+}
+
+// Parent returns the parent of this logger in the hierarchy of
+// named loggers, or nil if this logger has no parent.
+func (l *NamedLogger) Parent() *NamedLogger {
+	hierarchyMux.Lock()
+	defer hierarchyMux.Unlock()
+
+	if l.node == nil || l.node.parent == nil {
+		return nil
+	}
+	return l.node.parent.logger
+}
@@ -0,0 +1,126 @@
+package abc
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamedLogger_Child(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	app := newTestNamedLogger("app", LevelInfo, buf)
+	pool := app.Child("db.pool")
+	db := app.Child("db")
+
+	assert.Equal("app.db", db.Name())
+	assert.Equal("app.db.pool", pool.Name())
+	assert.True(db == pool.Parent())
+	assert.True(app == db.Parent())
+	assert.Nil(app.Parent())
+	assert.True(pool == db.Child("pool"), "Child must return the same logger for the same name")
+	assert.True(pool == app.Child(".db..pool."), "Empty name parts must be ignored")
+
+	pool.Info("abc")
+	assert.Equal("0001-01-01 00:00:00.000 <app.db.pool> [INFO] - abc\n", buf.String())
+
+	root := newTestNamedLogger("", LevelInfo, buf)
+	assert.Equal("app", root.Child("app").Name())
+}
+
+func TestNamedLogger_Child_Level(t *testing.T) {
+	assert := assert.New(t)
+
+	app := newTestNamedLogger("app", LevelInfo, &bytes.Buffer{})
+	db := app.Child("db")
+	pool := db.Child("pool")
+	http := app.Child("http")
+	assert.Equal(LevelInfo, pool.Level(), "Children must start with the level of the parent")
+
+	app.SetLevel(LevelDebug)
+	assert.Equal(LevelDebug, db.Level())
+	assert.Equal(LevelDebug, pool.Level())
+	assert.Equal(LevelDebug, http.Level())
+
+	db.SetLevel(LevelError)
+	app.SetLevel(LevelWarn)
+	assert.Equal(LevelError, db.Level(), "A level that was set must not be inherited")
+	assert.Equal(LevelError, pool.Level(), "Children must inherit the level that was set on their parent")
+	assert.Equal(LevelWarn, http.Level())
+	assert.False(pool.IsLevelEnabled(LevelWarn))
+
+	db.InheritLevel()
+	assert.Equal(LevelWarn, db.Level())
+	assert.Equal(LevelWarn, pool.Level())
+
+	pool.SetLevelString("error")
+	db.SetSubtreeLevel(LevelVerbose)
+	assert.Equal(LevelVerbose, db.Level())
+	assert.Equal(LevelVerbose, pool.Level(), "SetSubtreeLevel must override the levels of all children")
+	assert.Equal(LevelWarn, app.Level())
+
+	app.SetLevel(LevelFatal)
+	assert.Equal(LevelVerbose, pool.Level(), "The subtree must keep its level")
+	db.InheritLevel()
+	assert.Equal(LevelFatal, pool.Level(), "The children of the subtree must inherit their level again")
+}
+
+func TestNamedLogger_Child_Out(t *testing.T) {
+	assert := assert.New(t)
+
+	buf1, buf2, buf3 := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
+	app := newTestNamedLogger("app", LevelInfo, buf1)
+	db := app.Child("db")
+	http := app.Child("http")
+
+	app.SetOut(buf2)
+	http.SetOut(buf3)
+	db.Info("db")
+	http.Info("http")
+	assert.Equal("", buf1.String())
+	assert.Equal("0001-01-01 00:00:00.000 <app.db> [INFO] - db\n", buf2.String())
+	assert.Equal("0001-01-01 00:00:00.000 <app.http> [INFO] - http\n", buf3.String())
+
+	buf2.Reset()
+	http.InheritOut()
+	http.Info("http")
+	assert.Equal("0001-01-01 00:00:00.000 <app.http> [INFO] - http\n", buf2.String())
+}
+
+func TestNamedLogger_Child_With(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	app := newTestNamedLogger("app", LevelInfo, buf)
+	derived := app.With("request", 17).(*NamedLogger)
+	db := derived.Child("db")
+
+	app.SetLevel(LevelError)
+	assert.Equal(LevelInfo, derived.Level(), "Loggers derived with With must not be part of the hierarchy")
+
+	db.Info("abc")
+	assert.Equal("0001-01-01 00:00:00.000 <app.db> [INFO] - abc request=17\n", buf.String(), "Children must have the fields of their parent")
+}
+
+func TestNamedLogger_Child_Concurrent(t *testing.T) {
+	app := newTestNamedLogger("app", LevelInfo, &syncBuffer{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				db := app.Child("db").Child("pool")
+				db.Info("abc")
+				app.SetLevel(LogLevel(j % 3))
+				db.InheritLevel()
+				_ = db.Parent()
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,201 @@
+package abc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"unicode/utf8"
+)
+
+const (
+	// TimeLayoutJSONLogger is the time layout that the JSON logger
+	// uses for its timestamps.
+	TimeLayoutJSONLogger = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// Keys of the JSON objects that the JSON logger prints.
+// Fields that use one of these keys will appear twice
+// in the printed object.
+const (
+	JSONKeyTime    = "time"
+	JSONKeyLevel   = "level"
+	JSONKeyMessage = "message"
+	JSONKeyLogger  = "logger"
+	JSONKeyCaller  = "caller"
+)
+
+// JSONLogger is a logger that prints every message as
+// a single line JSON object (NDJSON).
+// The object contains the timestamp, the level, the message,
+// the name of the logger (if it has one), the caller and
+// all fields of the logger, e.g.
+//
+//	{"time":"2018-11-24T15:26:44.453Z","level":"INFO","message":"Hello World!","logger":"db","caller":"app/main.go:16","request":17}
+//
+// JSONLoggers are completely safe for concurrent use.
+type JSONLogger struct {
+	writerCore
+}
+
+// jsonFormatter is the formatter of the JSONLogger.
+type jsonFormatter struct{}
+
+func (jsonFormatter) Format(buf *bytes.Buffer, rec *Record) error {
+	buf.WriteByte('{')
+	writeJSONString(buf, JSONKeyTime)
+	buf.WriteString(`:"`)
+	buf.Write(rec.Time.AppendFormat(buf.AvailableBuffer(), TimeLayoutJSONLogger))
+	buf.WriteString(`",`)
+	writeJSONKeyString(buf, JSONKeyLevel, rec.Level.String())
+	buf.WriteByte(',')
+	writeJSONKeyString(buf, JSONKeyMessage, rec.Message)
+	if rec.Name != "" {
+		buf.WriteByte(',')
+		writeJSONKeyString(buf, JSONKeyLogger, rec.Name)
+	}
+	if frame := rec.Caller(); frame.File != "" {
+		buf.WriteByte(',')
+		writeJSONString(buf, JSONKeyCaller)
+		buf.WriteString(`:"`)
+		writeJSONStringContent(buf, filepath.Base(filepath.Dir(frame.File)))
+		buf.WriteByte('/')
+		writeJSONStringContent(buf, filepath.Base(frame.File))
+		buf.WriteByte(':')
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(frame.Line), 10))
+		buf.WriteByte('"')
+	}
+	for _, field := range rec.Fields {
+		buf.WriteByte(',')
+		writeJSONKeyValue(buf, field.Key, field.Value)
+	}
+	buf.WriteString("}\n")
+	return nil
+}
+
+// writeJSONKeyString writes "key":"value" to the given buffer.
+func writeJSONKeyString(buf *bytes.Buffer, key, value string) {
+	writeJSONString(buf, key)
+	buf.WriteByte(':')
+	writeJSONString(buf, value)
+}
+
+// writeJSONKeyValue writes "key":value to the given buffer.
+// Strings and errors are written as JSON strings, all other
+// values with encoding/json. Values that cannot be marshalled
+// are written as a string, formatted with fmt.Sprint.
+func writeJSONKeyValue(buf *bytes.Buffer, key string, value interface{}) {
+	writeJSONString(buf, key)
+	buf.WriteByte(':')
+	switch v := value.(type) {
+	case string:
+		writeJSONString(buf, v)
+	case error:
+		writeJSONString(buf, v.Error())
+	default:
+		if !writeJSONValue(buf, value) {
+			writeJSONString(buf, fmt.Sprint(value))
+		}
+	}
+}
+
+// writeJSONString writes the given string as a quoted JSON string
+// to the given buffer.
+func writeJSONString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	writeJSONStringContent(buf, s)
+	buf.WriteByte('"')
+}
+
+// writeJSONStringContent writes the given string to the given buffer,
+// escaped like encoding/json does without HTML escaping, but without
+// the surrounding quotes.
+// Invalid UTF-8 is written as the replacement character.
+func writeJSONStringContent(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' {
+				i++
+				continue
+			}
+			buf.WriteString(s[start:i])
+			switch b {
+			case '"', '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(b)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			default:
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hex[b>>4])
+				buf.WriteByte(hex[b&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			buf.WriteString(s[start:i])
+			buf.WriteRune(utf8.RuneError)
+		case r == '\u2028' || r == '\u2029':
+			// valid JSON, but not valid JavaScript
+			buf.WriteString(s[start:i])
+			buf.WriteString(`\u202`)
+			buf.WriteByte(hex[r&0xf])
+		default:
+			i += size
+			continue
+		}
+		i += size
+		start = i
+	}
+	buf.WriteString(s[start:])
+}
+
+// writeJSONValue writes the JSON encoding of the given value
+// to the given buffer, without escaping HTML characters.
+// If the value cannot be encoded, nothing is written
+// and false is returned.
+func writeJSONValue(buf *bytes.Buffer, value interface{}) bool {
+	tmp := &bytes.Buffer{}
+	enc := json.NewEncoder(tmp)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return false
+	}
+	buf.Write(bytes.TrimSuffix(tmp.Bytes(), []byte{'\n'}))
+	return true
+}
+
+// With returns a new JSONLogger that adds the given key/value
+// pairs to every printed object, in addition to the
+// fields of this logger.
+// The new logger starts with the level, clock, writer and name of this logger.
+func (l *JSONLogger) With(keyvals ...interface{}) Logger {
+	derived := &JSONLogger{}
+	l.derive(&derived.writerCore, keyvals...)
+	return derived
+}
+
+// Name returns the name of this logger.
+func (l *JSONLogger) Name() string {
+	return l.getName()
+}
+
+// SetName sets a new name for this logger.
+// If the name is empty, the logger key is omitted
+// from the printed objects.
+func (l *JSONLogger) SetName(name string) {
+	l.setName(name)
+}
@@ -0,0 +1,16 @@
+package abc
+
+import (
+	"testing"
+)
+
+func BenchmarkJSONLogger_Printf(b *testing.B) {
+	logger := newTestJSONLogger("", LevelVerbose, &MockWriter{})
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.Printf(LevelVerbose, "formatted: %v", "some input")
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package abc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONLogger_All_Outputs(t *testing.T) {
+	assert := assert.New(t)
+
+	expectations := []string{
+		`{"time":"0001-01-01T00:00:00.000Z","level":"DEBG","message":"verbose: abc"}` + "\n",
+		`{"time":"0001-01-01T00:00:00.000Z","level":"DEBG","message":"verbose: fmt: abc"}` + "\n",
+		`{"time":"0001-01-01T00:00:00.000Z","level":"DEBG","message":"abc"}` + "\n",
+		`{"time":"0001-01-01T00:00:00.000Z","level":"DEBG","message":"fmt: abc"}` + "\n",
+		`{"time":"0001-01-01T00:00:00.000Z","level":"INFO","message":"abc"}` + "\n",
+		`{"time":"0001-01-01T00:00:00.000Z","level":"INFO","message":"fmt: abc"}` + "\n",
+		`{"time":"0001-01-01T00:00:00.000Z","level":"WARN","message":"abc"}` + "\n",
+		`{"time":"0001-01-01T00:00:00.000Z","level":"WARN","message":"fmt: abc"}` + "\n",
+		`{"time":"0001-01-01T00:00:00.000Z","level":"ERR","message":"abc"}` + "\n",
+		`{"time":"0001-01-01T00:00:00.000Z","level":"ERR","message":"fmt: abc"}` + "\n",
+		`{"time":"0001-01-01T00:00:00.000Z","level":"FATAL","message":"abc"}` + "\n",
+		`{"time":"0001-01-01T00:00:00.000Z","level":"FATAL","message":"fmt: abc"}` + "\n",
+	}
+	cnt := 0
+
+	buf := &bytes.Buffer{}
+
+	logger := newTestJSONLogger("", LevelVerbose, buf)
+
+	check := func() {
+		defer func() {
+			cnt++
+		}()
+		defer buf.Reset()
+
+		if cnt >= len(expectations) {
+			panic("No more expectations")
+		}
+
+		var obj map[string]interface{}
+		assert.NoError(json.Unmarshal(buf.Bytes(), &obj), "Output is not valid JSON")
+		assert.Regexp(`^[^/]+/json_logger_test.go:\d+$`, obj["caller"], "Wrong caller")
+
+		delete(obj, "caller")
+		var expected map[string]interface{}
+		_ = json.Unmarshal([]byte(expectations[cnt]), &expected)
+		assert.Equal(expected, obj, "Wrong output")
+	}
+
+	// actual test flow
+
+	logger.Verbose("verbose: abc")
+	check()
+	logger.Verbosef("verbose: fmt: %v", "abc")
+	check()
+	logger.Debug("abc")
+	check()
+	logger.Debugf("fmt: %v", "abc")
+	check()
+	logger.Info("abc")
+	check()
+	logger.Infof("fmt: %v", "abc")
+	check()
+	logger.Warn("abc")
+	check()
+	logger.Warnf("fmt: %v", "abc")
+	check()
+	logger.Error("abc")
+	check()
+	logger.Errorf("fmt: %v", "abc")
+	check()
+	logger.Fatal("abc")
+	check()
+	logger.Fatalf("fmt: %v", "abc")
+	check()
+}
+
+func TestJSONLogger_Format(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	logger := newTestJSONLogger("db", LevelInfo, buf)
+
+	logger.With(
+		"request", 17,
+		"ok", true,
+		"err", errors.New("connection refused"),
+		"tags", []string{"a", "b"},
+		"ch", make(chan int),
+	).Info("line one\nsaid \"<hello>\"")
+	assert.Regexp(`^\{"time":"0001-01-01T00:00:00.000Z","level":"INFO","message":"line one\\nsaid \\"<hello>\\"","logger":"db","caller":"[^/"]+/json_logger_test.go:\d+","request":17,"ok":true,"err":"connection refused","tags":\["a","b"\],"ch":"0x[0-9a-f]+"\}\n$`, buf.String())
+}
+
+func TestJSONLogger_ValidJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	logger := newTestJSONLogger("db\\\"x", LevelDebug, buf)
+
+	logger.With(
+		"path", `C:\logs\app.log`,
+		"quote\"key", "line\nbreak",
+		"invalid", "\xff",
+	).Debugf("tab\t%s", "\x00")
+
+	assert.True(json.Valid(buf.Bytes()), buf.String())
+
+	var out map[string]interface{}
+	assert.NoError(json.Unmarshal(buf.Bytes(), &out))
+	assert.Regexp(`^[^/\\]+/json_logger_test.go:\d+$`, out[JSONKeyCaller])
+	assert.Equal(`C:\logs\app.log`, out["path"])
+}
+
+func TestWriteJSONString(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, s := range []string{
+		"",
+		"plain",
+		"quote \" and backslash \\",
+		"<html> & stuff",
+		"tab\tnewline\ncr\r\x00\x1f\x7f",
+		"ünïcödé 日本語",
+		"invalid \xff\xfe utf8",
+		"line\u2028paragraph\u2029",
+	} {
+		buf := &bytes.Buffer{}
+		writeJSONString(buf, s)
+
+		expected := &bytes.Buffer{}
+		enc := json.NewEncoder(expected)
+		enc.SetEscapeHTML(false)
+		assert.NoError(enc.Encode(s))
+		assert.Equal(bytes.TrimSuffix(expected.Bytes(), []byte{'\n'}), buf.Bytes(), "%q", s)
+	}
+}
+
+func TestJSONLogger_SetOut(t *testing.T) {
+	assert := assert.New(t)
+
+	buf1 := &bytes.Buffer{}
+	buf2 := &bytes.Buffer{}
+
+	logger := newTestJSONLogger("", LevelVerbose, buf1)
+
+	logger.Info("foo")
+	assert.Contains(buf1.String(), `"message":"foo"`, "buf1 did receive wrong output.")
+	assert.Equal("", buf2.String(), "buf2 did receive output.")
+
+	buf1.Reset()
+
+	logger.SetOut(buf2) // setting new out
+
+	logger.Info("bar")
+	assert.Equal("", buf1.String(), "buf1 did receive output.")
+	assert.Contains(buf2.String(), `"message":"bar"`, "buf2 did receive wrong output.")
+}
+
+func TestJSONLogger_SetLevel(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	logger := newTestJSONLogger("", LevelVerbose, buf)
+
+	logger.Verbose("foo")
+	assert.Contains(buf.String(), `"level":"DEBG","message":"foo"`, "buf did receive wrong output.")
+
+	buf.Reset()                // reset buffer
+	logger.SetLevel(LevelInfo) // set new level
+
+	logger.Verbose("foo")
+	assert.Equal("", buf.String(), "buf did receive output.")
+}
@@ -1,6 +1,9 @@
 package abc
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 // LogLevel is an alias and represents a log level.
 type LogLevel uint8
@@ -41,20 +44,32 @@ func (l LogLevel) String() string {
 	return ""
 }
 
+// ToLogLevel returns the level with the given name like
+// ParseLogLevel, or LevelWarn if there is no such level.
 func ToLogLevel(levelName string) LogLevel {
+	if lvl, err := ParseLogLevel(levelName); err == nil {
+		return lvl
+	}
+	return LevelWarn
+}
+
+// ParseLogLevel returns the level with the given name, which is
+// one of verbose, debug, info, warn, error and fatal, or one of the
+// names of LogLevel.String, ignoring the case.
+func ParseLogLevel(levelName string) (LogLevel, error) {
 	switch strings.ToLower(levelName) {
 	case "verbose":
-		return LevelVerbose
+		return LevelVerbose, nil
 	case "debug", "debg":
-		return LevelDebug
+		return LevelDebug, nil
 	case "info":
-		return LevelInfo
+		return LevelInfo, nil
 	case "warn":
-		return LevelWarn
+		return LevelWarn, nil
 	case "error", "err":
-		return LevelError
+		return LevelError, nil
 	case "fatal":
-		return LevelFatal
+		return LevelFatal, nil
 	}
-	return LevelWarn
+	return 0, fmt.Errorf("unknown log level %q", levelName)
 }
@@ -0,0 +1,260 @@
+package abc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// levelAdmin is the http.Handler of NewLevelAdminHandler.
+type levelAdmin struct {
+	clk clock
+
+	mu sync.Mutex
+	// overrides are the temporary levels by the name of the logger.
+	overrides map[string]*levelOverride
+}
+
+// levelOverride is a temporary level of a logger, after which
+// the previous level of the logger is restored.
+type levelOverride struct {
+	lvl     LogLevel
+	expires time.Time
+	// prev and prevInherited are the level of the logger before
+	// the first of consecutive temporary levels.
+	prev          LogLevel
+	prevInherited bool
+	// cancel is closed when the override ends before it expires.
+	cancel chan struct{}
+}
+
+// levelAdminLogger is the JSON representation of a logger.
+type levelAdminLogger struct {
+	Name      string `json:"name"`
+	Level     string `json:"level"`
+	Inherited bool   `json:"inherited"`
+	// Expires is the time, when a temporary level expires.
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// levelAdminRequest is the JSON body of a PUT request.
+type levelAdminRequest struct {
+	Level    string `json:"level"`
+	Duration string `json:"duration"`
+}
+
+func newLevelAdmin(clk clock) *levelAdmin {
+	return &levelAdmin{
+		clk:       clk,
+		overrides: make(map[string]*levelOverride),
+	}
+}
+
+func (h *levelAdmin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.expireDue()
+
+	name := strings.Trim(r.URL.Path, "/")
+	if name == "" {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.list(w)
+		return
+	}
+
+	logger, ok := lookupLogger(name)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown logger %q", name), http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.writeJSON(w, h.describe(logger))
+	case http.MethodPut:
+		h.put(w, r, logger)
+	case http.MethodDelete:
+		if !h.cancel(logger) {
+			http.Error(w, fmt.Sprintf("logger %q has no temporary level", name), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// list writes all loggers of the registry.
+func (h *levelAdmin) list(w http.ResponseWriter) {
+	loggers := []levelAdminLogger{}
+	for _, logger := range Loggers() {
+		loggers = append(loggers, h.describe(logger))
+	}
+	h.writeJSON(w, loggers)
+}
+
+// put sets the level of the given logger, which is temporary
+// if the request has a duration.
+func (h *levelAdmin) put(w http.ResponseWriter, r *http.Request, logger *NamedLogger) {
+	var req levelAdminRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	lvl, err := ParseLogLevel(req.Level)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var d time.Duration
+	if req.Duration != "" {
+		d, err = time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			http.Error(w, fmt.Sprintf("invalid duration %q, expected a positive duration like 10m", req.Duration), http.StatusBadRequest)
+			return
+		}
+	}
+
+	h.setLevel(logger, lvl, d)
+	h.writeJSON(w, h.describe(logger))
+}
+
+// setLevel sets the level of the given logger.
+// If d is positive, the previous level is restored after d.
+func (h *levelAdmin) setLevel(logger *NamedLogger, lvl LogLevel, d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	name := registryName(logger)
+	prev, prevInherited := logger.Level(), logger.InheritsLevel()
+	if old, ok := h.overrides[name]; ok {
+		prev, prevInherited = old.prev, old.prevInherited
+		close(old.cancel)
+		delete(h.overrides, name)
+	}
+
+	logger.SetLevel(lvl)
+	if d <= 0 {
+		return
+	}
+
+	override := &levelOverride{
+		lvl:           lvl,
+		expires:       h.clk.Now().Add(d),
+		prev:          prev,
+		prevInherited: prevInherited,
+		cancel:        make(chan struct{}),
+	}
+	h.overrides[name] = override
+	go h.expireAfter(logger, override, h.clk.After(d))
+}
+
+// expireAfter ends the given override, when the given channel
+// receives, unless the override was canceled before.
+func (h *levelAdmin) expireAfter(logger *NamedLogger, override *levelOverride, after <-chan time.Time) {
+	select {
+	case <-after:
+	case <-override.cancel:
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.overrides[registryName(logger)] == override {
+		h.end(logger, override)
+	}
+}
+
+// expireDue ends all overrides, that expired according to the
+// clock, in case their timers didn't fire yet.
+func (h *levelAdmin) expireDue() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.clk.Now()
+	for name, override := range h.overrides {
+		if !now.Before(override.expires) {
+			if logger, ok := lookupLogger(name); ok {
+				h.end(logger, override)
+			}
+			delete(h.overrides, name)
+		}
+	}
+}
+
+// cancel ends the temporary level of the given logger and
+// returns whether it had one.
+func (h *levelAdmin) cancel(logger *NamedLogger) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	override, ok := h.overrides[registryName(logger)]
+	if ok {
+		h.end(logger, override)
+	}
+	return ok
+}
+
+// end removes the given override and restores the previous level,
+// unless the level was changed otherwise in the meantime.
+// h.mu must be held.
+func (h *levelAdmin) end(logger *NamedLogger, override *levelOverride) {
+	select {
+	case <-override.cancel:
+	default:
+		close(override.cancel)
+	}
+	delete(h.overrides, registryName(logger))
+
+	if logger.Level() != override.lvl {
+		return
+	}
+	if override.prevInherited {
+		logger.InheritLevel()
+	} else {
+		logger.SetLevel(override.prev)
+	}
+}
+
+// describe returns the JSON representation of the given logger.
+func (h *levelAdmin) describe(logger *NamedLogger) levelAdminLogger {
+	desc := levelAdminLogger{
+		Name:      registryName(logger),
+		Level:     levelName(logger.Level()),
+		Inherited: logger.InheritsLevel(),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if override, ok := h.overrides[desc.Name]; ok {
+		expires := override.expires
+		desc.Expires = &expires
+	}
+	return desc
+}
+
+func (h *levelAdmin) writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// levelName returns the name of the given level, that
+// ParseLogLevel accepts. It is the name of the level in logfmt,
+// except for LevelVerbose, which is not printed as debug, so
+// that it can be told apart.
+func levelName(lvl LogLevel) string {
+	if lvl == LevelVerbose {
+		return "verbose"
+	}
+	return logfmtLevel(lvl)
+}
@@ -0,0 +1,207 @@
+package abc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// serveLevelAdmin serves a request with the given method, path and body.
+func serveLevelAdmin(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return rec
+}
+
+// decodeLevelAdminLogger decodes a logger from the given response.
+func decodeLevelAdminLogger(t *testing.T, rec *httptest.ResponseRecorder) levelAdminLogger {
+	var logger levelAdminLogger
+	if err := json.Unmarshal(rec.Body.Bytes(), &logger); err != nil {
+		t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+	}
+	return logger
+}
+
+func TestLevelAdmin_List(t *testing.T) {
+	assert := assert.New(t)
+
+	GetLogger("adminlist.db").SetLevel(LevelDebug)
+	h := newLevelAdmin(&manualClock{})
+
+	rec := serveLevelAdmin(h, http.MethodGet, "/", "")
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal("application/json", rec.Header().Get("Content-Type"))
+
+	var loggers []levelAdminLogger
+	if !assert.NoError(json.Unmarshal(rec.Body.Bytes(), &loggers)) {
+		return
+	}
+	var listed []levelAdminLogger
+	for _, logger := range loggers {
+		if strings.HasPrefix(logger.Name, "adminlist") {
+			listed = append(listed, logger)
+		}
+	}
+	assert.Equal([]levelAdminLogger{
+		{Name: "adminlist", Level: levelName(GetLogger("").Level()), Inherited: true},
+		{Name: "adminlist.db", Level: "debug"},
+	}, listed)
+}
+
+func TestLevelAdmin_GetPut(t *testing.T) {
+	assert := assert.New(t)
+
+	db := GetLogger("adminput.db")
+	pool := GetLogger("adminput.db.pool")
+	db.SetLevel(LevelInfo)
+	h := newLevelAdmin(&manualClock{})
+
+	rec := serveLevelAdmin(h, http.MethodGet, "/adminput.db", "")
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal(levelAdminLogger{Name: "adminput.db", Level: "info"}, decodeLevelAdminLogger(t, rec))
+
+	rec = serveLevelAdmin(h, http.MethodPut, "/adminput.db/", `{"level": "debug"}`)
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal(levelAdminLogger{Name: "adminput.db", Level: "debug"}, decodeLevelAdminLogger(t, rec))
+	assert.True(pool.IsLevelEnabled(LevelDebug), "Children must inherit the level")
+
+	rec = serveLevelAdmin(h, http.MethodGet, "/adminput.db.pool", "")
+	assert.Equal(levelAdminLogger{Name: "adminput.db.pool", Level: "debug", Inherited: true}, decodeLevelAdminLogger(t, rec))
+}
+
+func TestLevelAdmin_Root(t *testing.T) {
+	assert := assert.New(t)
+
+	root := GetLogger("")
+	root.SetLevel(LevelInfo)
+	h := newLevelAdmin(&manualClock{})
+
+	rec := serveLevelAdmin(h, http.MethodGet, "/", "")
+	var loggers []levelAdminLogger
+	if assert.NoError(json.Unmarshal(rec.Body.Bytes(), &loggers)) && assert.NotEmpty(loggers) {
+		assert.Equal(levelAdminLogger{Name: RootLoggerName, Level: "info"}, loggers[0], "The root must be listed by its name")
+	}
+
+	rec = serveLevelAdmin(h, http.MethodPut, "/root", `{"level": "error", "duration": "1h"}`)
+	assert.Equal(http.StatusOK, rec.Code)
+	assert.Equal(LevelError, root.Level())
+
+	rec = serveLevelAdmin(h, http.MethodDelete, "/root", "")
+	assert.Equal(http.StatusNoContent, rec.Code)
+	assert.Equal(LevelInfo, root.Level())
+}
+
+func TestLevelAdmin_Errors(t *testing.T) {
+	GetLogger("adminerrors")
+	h := newLevelAdmin(&manualClock{})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+		allow  string
+		err    string
+	}{
+		{"unknown logger", http.MethodGet, "/adminerrors.unknown", "", http.StatusNotFound, "", `unknown logger "adminerrors.unknown"`},
+		{"invalid body", http.MethodPut, "/adminerrors", `{"level":`, http.StatusBadRequest, "", "invalid request: unexpected EOF"},
+		{"invalid level", http.MethodPut, "/adminerrors", `{"level": "loud"}`, http.StatusBadRequest, "", `unknown log level "loud"`},
+		{"invalid duration", http.MethodPut, "/adminerrors", `{"level": "debug", "duration": "10"}`, http.StatusBadRequest, "", `invalid duration "10", expected a positive duration like 10m`},
+		{"negative duration", http.MethodPut, "/adminerrors", `{"level": "debug", "duration": "-1m"}`, http.StatusBadRequest, "", `invalid duration "-1m", expected a positive duration like 10m`},
+		{"no temporary level", http.MethodDelete, "/adminerrors", "", http.StatusNotFound, "", `logger "adminerrors" has no temporary level`},
+		{"method of logger", http.MethodPost, "/adminerrors", "", http.StatusMethodNotAllowed, "GET, PUT, DELETE", "method not allowed"},
+		{"method of list", http.MethodPut, "/", "", http.StatusMethodNotAllowed, "GET", "method not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rec := serveLevelAdmin(h, tt.method, tt.path, tt.body)
+			assert.Equal(tt.code, rec.Code)
+			assert.Equal(tt.allow, rec.Header().Get("Allow"))
+			assert.Equal(tt.err+"\n", rec.Body.String())
+		})
+	}
+	assert.Equal(t, LevelInfo, GetLogger("adminerrors").Level(), "Invalid requests must not change the level")
+}
+
+func TestLevelAdmin_Temporary(t *testing.T) {
+	assert := assert.New(t)
+
+	GetLogger("admintemp").SetLevel(LevelWarn)
+	logger := GetLogger("admintemp.db")
+	logger.InheritLevel()
+	start := time.Date(2018, 11, 24, 20, 10, 55, 0, time.UTC)
+	clk := &manualClock{now: start}
+	h := newLevelAdmin(clk)
+
+	rec := serveLevelAdmin(h, http.MethodPut, "/admintemp.db", `{"level": "debug", "duration": "10m"}`)
+	assert.Equal(http.StatusOK, rec.Code)
+	expires := start.Add(10 * time.Minute)
+	assert.Equal(levelAdminLogger{Name: "admintemp.db", Level: "debug", Expires: &expires}, decodeLevelAdminLogger(t, rec))
+	assert.True(logger.IsLevelEnabled(LevelDebug))
+
+	clk.Set(start.Add(5 * time.Minute))
+	rec = serveLevelAdmin(h, http.MethodPut, "/admintemp.db", `{"level": "verbose", "duration": "10m"}`)
+	assert.Equal(LevelVerbose, logger.Level())
+
+	clk.Set(start.Add(14 * time.Minute))
+	rec = serveLevelAdmin(h, http.MethodGet, "/admintemp.db", "")
+	expires = start.Add(15 * time.Minute)
+	assert.Equal(levelAdminLogger{Name: "admintemp.db", Level: "verbose", Expires: &expires}, decodeLevelAdminLogger(t, rec))
+
+	clk.Set(start.Add(15 * time.Minute))
+	rec = serveLevelAdmin(h, http.MethodGet, "/admintemp.db", "")
+	assert.Equal(levelAdminLogger{Name: "admintemp.db", Level: "warn", Inherited: true}, decodeLevelAdminLogger(t, rec), "The level before the first temporary level must be restored")
+	assert.False(logger.IsLevelEnabled(LevelInfo))
+
+	serveLevelAdmin(h, http.MethodPut, "/admintemp.db", `{"level": "debug", "duration": "10m"}`)
+	rec = serveLevelAdmin(h, http.MethodPut, "/admintemp.db", `{"level": "error"}`)
+	assert.Equal(levelAdminLogger{Name: "admintemp.db", Level: "error"}, decodeLevelAdminLogger(t, rec), "A level without duration must end the temporary level")
+	clk.Set(start.Add(time.Hour))
+	serveLevelAdmin(h, http.MethodGet, "/", "")
+	assert.Equal(LevelError, logger.Level())
+}
+
+func TestLevelAdmin_Temporary_Delete(t *testing.T) {
+	assert := assert.New(t)
+
+	logger := GetLogger("admindelete")
+	logger.SetLevel(LevelError)
+	h := newLevelAdmin(&manualClock{})
+
+	serveLevelAdmin(h, http.MethodPut, "/admindelete", `{"level": "debug", "duration": "1h"}`)
+	rec := serveLevelAdmin(h, http.MethodDelete, "/admindelete", "")
+	assert.Equal(http.StatusNoContent, rec.Code)
+	assert.Equal(LevelError, logger.Level())
+	assert.False(logger.InheritsLevel())
+
+	serveLevelAdmin(h, http.MethodPut, "/admindelete", `{"level": "debug", "duration": "1h"}`)
+	logger.SetLevel(LevelFatal)
+	serveLevelAdmin(h, http.MethodDelete, "/admindelete", "")
+	assert.Equal(LevelFatal, logger.Level(), "Levels that were changed otherwise must not be restored")
+}
+
+func TestLevelAdmin_Temporary_Timer(t *testing.T) {
+	logger := GetLogger("admintimer")
+	logger.SetLevel(LevelInfo)
+	clk := &manualClock{ticks: make(chan time.Time)}
+	h := newLevelAdmin(clk)
+
+	serveLevelAdmin(h, http.MethodPut, "/admintimer", `{"level": "debug", "duration": "1m"}`)
+	clk.ticks <- time.Time{}
+
+	deadline := time.Now().Add(time.Second)
+	for logger.Level() != LevelInfo {
+		if time.Now().After(deadline) {
+			t.Fatal("The temporary level did not expire without requests")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,91 @@
+package abc
+
+import (
+	"testing"
+)
+
+// disabledLevelCall is a log call of a concrete logger type with
+// a disabled level.
+// The methods are called on the concrete types, because calls through
+// the Logger interface always allocate the slice of the variadic
+// arguments, as the compiler can't prove that it does not escape.
+type disabledLevelCall struct {
+	name    string
+	info    func()
+	infof   func()
+	enabled func() bool
+}
+
+// disabledLevelCalls returns calls for loggers of every kind, that
+// have the level WARN and would print to a MockWriter.
+func disabledLevelCalls() []disabledLevelCall {
+	simple := newTestSimpleLogger(LevelWarn, &MockWriter{})
+	named := newTestNamedLogger("MyLogger", LevelWarn, &MockWriter{})
+	pattern := newTestCustomPatternLogger(CustomPatternLoggerDefaultPattern, LevelWarn, &MockWriter{})
+	json := newTestJSONLogger("MyLogger", LevelWarn, &MockWriter{})
+	colored := NewColoredLogger(newTestSimpleLogger(LevelWarn, &MockWriter{})).(*ColoredLogger)
+	with := simple.With("request", 17).(*SimpleLogger)
+
+	return []disabledLevelCall{
+		{"SimpleLogger", func() { simple.Info("some input") }, func() { simple.Infof("formatted: %v", "some input") }, func() bool { return simple.IsLevelEnabled(LevelInfo) }},
+		{"NamedLogger", func() { named.Info("some input") }, func() { named.Infof("formatted: %v", "some input") }, func() bool { return named.IsLevelEnabled(LevelInfo) }},
+		{"CustomPatternLogger", func() { pattern.Info("some input") }, func() { pattern.Infof("formatted: %v", "some input") }, func() bool { return pattern.IsLevelEnabled(LevelInfo) }},
+		{"JSONLogger", func() { json.Info("some input") }, func() { json.Infof("formatted: %v", "some input") }, func() bool { return json.IsLevelEnabled(LevelInfo) }},
+		{"ColoredLogger", func() { colored.Info("some input") }, func() { colored.Infof("formatted: %v", "some input") }, func() bool { return colored.IsLevelEnabled(LevelInfo) }},
+		{"With", func() { with.Info("some input") }, func() { with.Infof("formatted: %v", "some input") }, func() bool { return with.IsLevelEnabled(LevelInfo) }},
+	}
+}
+
+func BenchmarkDisabledLevel_Info(b *testing.B) {
+	for _, call := range disabledLevelCalls() {
+		b.Run(call.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					call.info()
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkDisabledLevel_Infof(b *testing.B) {
+	for _, call := range disabledLevelCalls() {
+		b.Run(call.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					call.infof()
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkDisabledLevel_IsLevelEnabled(b *testing.B) {
+	for _, call := range disabledLevelCalls() {
+		b.Run(call.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_ = call.enabled()
+				}
+			})
+		})
+	}
+}
+
+func TestDisabledLevel_NoAllocs(t *testing.T) {
+	for _, call := range disabledLevelCalls() {
+		t.Run(call.name, func(t *testing.T) {
+			allocs := testing.AllocsPerRun(100, func() {
+				call.info()
+				call.infof()
+				_ = call.enabled()
+			})
+			if allocs != 0 {
+				t.Errorf("Calls with a disabled level must not allocate, but allocated %v times", allocs)
+			}
+		})
+	}
+}
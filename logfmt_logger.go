@@ -0,0 +1,162 @@
+package abc
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// TimeLayoutLogfmtLogger is the time layout that the logfmt logger
+	// uses for its timestamps.
+	TimeLayoutLogfmtLogger = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// Keys of the records that the logfmt logger prints.
+const (
+	LogfmtKeyTime    = "ts"
+	LogfmtKeyLevel   = "level"
+	LogfmtKeyLogger  = "logger"
+	LogfmtKeyMessage = "msg"
+)
+
+// LogfmtLogger is a logger that prints every message as
+// a single line of logfmt key/value pairs.
+// Every line contains the timestamp, the level, the name of
+// the logger (if it has one), the message and all fields of
+// the logger, e.g.
+//
+//	ts=2018-11-24T15:26:44.453Z level=info logger=db msg="Hello World!" request=17
+//
+// Values are quoted if they are empty or contain spaces, equal
+// signs, quotes, control characters or invalid UTF-8.
+// Inside quotes, backslashes, quotes and control characters
+// are escaped.
+// Characters that are not allowed in keys are replaced with
+// an underscore.
+//
+// LogfmtLoggers are completely safe for concurrent use.
+type LogfmtLogger struct {
+	writerCore
+}
+
+// logfmtFormatter is the formatter of the LogfmtLogger.
+type logfmtFormatter struct{}
+
+func (logfmtFormatter) Format(buf *bytes.Buffer, rec *Record) error {
+	writeLogfmtKeyValue(buf, LogfmtKeyTime, rec.Time.Format(TimeLayoutLogfmtLogger))
+	buf.WriteByte(' ')
+	writeLogfmtKeyValue(buf, LogfmtKeyLevel, logfmtLevel(rec.Level))
+	if rec.Name != "" {
+		buf.WriteByte(' ')
+		writeLogfmtKeyValue(buf, LogfmtKeyLogger, rec.Name)
+	}
+	buf.WriteByte(' ')
+	writeLogfmtKeyValue(buf, LogfmtKeyMessage, rec.Message)
+	for _, field := range rec.Fields {
+		buf.WriteByte(' ')
+		writeLogfmtKeyValue(buf, field.Key, fmt.Sprint(field.Value))
+	}
+	buf.WriteByte('\n')
+	return nil
+}
+
+// logfmtLevel returns the lower case level name, that
+// log processors like Loki recognize.
+func logfmtLevel(lvl LogLevel) string {
+	switch lvl {
+	case LevelVerbose, LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	case LevelFatal:
+		return "fatal"
+	}
+	return strings.ToLower(lvl.String())
+}
+
+// writeLogfmtKeyValue writes key=value to the given buffer,
+// replacing invalid characters in the key and quoting the
+// value if necessary.
+func writeLogfmtKeyValue(buf *bytes.Buffer, key, value string) {
+	if key == "" {
+		key = "_"
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || r == 0x7f {
+			buf.WriteByte('_')
+		} else {
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('=')
+
+	if !logfmtNeedsQuoting(value) {
+		buf.WriteString(value)
+		return
+	}
+
+	buf.WriteByte('"')
+	for i := 0; i < len(value); {
+		r, size := utf8.DecodeRuneInString(value[i:])
+		i += size
+
+		switch {
+		case r == '\\' || r == '"':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r == '\n':
+			buf.WriteString(`\n`)
+		case r == '\r':
+			buf.WriteString(`\r`)
+		case r == '\t':
+			buf.WriteString(`\t`)
+		case r < ' ' || r == 0x7f:
+			fmt.Fprintf(buf, `\u%04x`, r)
+		default:
+			// invalid UTF-8 was decoded to utf8.RuneError and
+			// is written as the replacement character
+			buf.WriteRune(r)
+		}
+	}
+	buf.WriteByte('"')
+}
+
+func logfmtNeedsQuoting(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f || r == utf8.RuneError {
+			return true
+		}
+	}
+	return false
+}
+
+// With returns a new LogfmtLogger that appends the given key/value
+// pairs to every printed record, in addition to the
+// fields of this logger.
+// The new logger starts with the level, clock, writer and name of this logger.
+func (l *LogfmtLogger) With(keyvals ...interface{}) Logger {
+	derived := &LogfmtLogger{}
+	l.derive(&derived.writerCore, keyvals...)
+	return derived
+}
+
+// Name returns the name of this logger.
+func (l *LogfmtLogger) Name() string {
+	return l.getName()
+}
+
+// SetName sets a new name for this logger.
+// If the name is empty, the logger key is omitted
+// from the printed records.
+func (l *LogfmtLogger) SetName(name string) {
+	l.setName(name)
+}
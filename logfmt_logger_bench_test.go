@@ -0,0 +1,16 @@
+package abc
+
+import (
+	"testing"
+)
+
+func BenchmarkLogfmtLogger_Printf(b *testing.B) {
+	logger := newTestLogfmtLogger("", LevelVerbose, &MockWriter{})
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.Printf(LevelVerbose, "formatted: %v", "some input")
+		}
+	})
+}
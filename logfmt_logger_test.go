@@ -0,0 +1,150 @@
+package abc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogfmtLogger_All_Outputs(t *testing.T) {
+	assert := assert.New(t)
+
+	expectations := []string{
+		"ts=0001-01-01T00:00:00.000Z level=debug msg=\"verbose: abc\"\n",
+		"ts=0001-01-01T00:00:00.000Z level=debug msg=\"verbose: fmt: abc\"\n",
+		"ts=0001-01-01T00:00:00.000Z level=debug msg=abc\n",
+		"ts=0001-01-01T00:00:00.000Z level=debug msg=\"fmt: abc\"\n",
+		"ts=0001-01-01T00:00:00.000Z level=info msg=abc\n",
+		"ts=0001-01-01T00:00:00.000Z level=info msg=\"fmt: abc\"\n",
+		"ts=0001-01-01T00:00:00.000Z level=warn msg=abc\n",
+		"ts=0001-01-01T00:00:00.000Z level=warn msg=\"fmt: abc\"\n",
+		"ts=0001-01-01T00:00:00.000Z level=error msg=abc\n",
+		"ts=0001-01-01T00:00:00.000Z level=error msg=\"fmt: abc\"\n",
+		"ts=0001-01-01T00:00:00.000Z level=fatal msg=abc\n",
+		"ts=0001-01-01T00:00:00.000Z level=fatal msg=\"fmt: abc\"\n",
+	}
+	cnt := 0
+
+	buf := &bytes.Buffer{}
+
+	logger := newTestLogfmtLogger("", LevelVerbose, buf)
+
+	check := func() {
+		defer func() {
+			cnt++
+		}()
+		defer buf.Reset()
+
+		if cnt >= len(expectations) {
+			panic("No more expectations")
+		}
+
+		assert.Equal(expectations[cnt], buf.String(), "Wrong output")
+	}
+
+	// actual test flow
+
+	logger.Verbose("verbose: abc")
+	check()
+	logger.Verbosef("verbose: fmt: %v", "abc")
+	check()
+	logger.Debug("abc")
+	check()
+	logger.Debugf("fmt: %v", "abc")
+	check()
+	logger.Info("abc")
+	check()
+	logger.Infof("fmt: %v", "abc")
+	check()
+	logger.Warn("abc")
+	check()
+	logger.Warnf("fmt: %v", "abc")
+	check()
+	logger.Error("abc")
+	check()
+	logger.Errorf("fmt: %v", "abc")
+	check()
+	logger.Fatal("abc")
+	check()
+	logger.Fatalf("fmt: %v", "abc")
+	check()
+}
+
+func TestLogfmtLogger_Quoting(t *testing.T) {
+	logger := newTestLogfmtLogger("db", LevelInfo, nil)
+
+	tests := []struct {
+		name     string
+		keyvals  []interface{}
+		expected string
+	}{
+		{
+			"Plain value",
+			[]interface{}{"key", "value"},
+			`key=value`,
+		},
+		{
+			"Numbers and booleans",
+			[]interface{}{"int", 17, "float", -0.5, "bool", true},
+			`int=17 float=-0.5 bool=true`,
+		},
+		{
+			"Empty value",
+			[]interface{}{"key", ""},
+			`key=""`,
+		},
+		{
+			"Spaces, equal signs and quotes",
+			[]interface{}{"space", "a b", "equals", "a=b", "quote", `a"b`},
+			`space="a b" equals="a=b" quote="a\"b"`,
+		},
+		{
+			"Backslashes are only escaped in quoted values",
+			[]interface{}{"plain", `C:\dir`, "quoted", `C:\my dir`},
+			`plain=C:\dir quoted="C:\\my dir"`,
+		},
+		{
+			"Control characters",
+			[]interface{}{"key", "a\nb\tc\rd\x00e"},
+			`key="a\nb\tc\rd\u0000e"`,
+		},
+		{
+			"Unicode and invalid UTF-8",
+			[]interface{}{"unicode", "äöü", "invalid", "a\xffb"},
+			"unicode=äöü invalid=\"a\uFFFDb\"",
+		},
+		{
+			"Invalid keys",
+			[]interface{}{"my key", 1, "a=b", 2, "", 3},
+			`my_key=1 a_b=2 _=3`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			l := logger.With(tt.keyvals...).(*LogfmtLogger)
+			l.SetOut(buf)
+			l.Info("a \"quoted\" message\n")
+			assert.Equal(t, `ts=0001-01-01T00:00:00.000Z level=info logger=db msg="a \"quoted\" message\n" `+tt.expected+"\n", buf.String())
+		})
+	}
+}
+
+func TestLogfmtLogger_SetLevel(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	logger := newTestLogfmtLogger("", LevelVerbose, buf)
+
+	logger.Verbose("foo")
+	assert.Equal("ts=0001-01-01T00:00:00.000Z level=debug msg=foo\n", buf.String(), "buf did receive wrong output.")
+
+	buf.Reset()                // reset buffer
+	logger.SetLevel(LevelInfo) // set new level
+
+	logger.Verbose("foo")
+	assert.Equal("", buf.String(), "buf did receive output.")
+}
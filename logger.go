@@ -59,3 +59,21 @@ type Logger interface {
 	// False otherwise.
 	IsLevelEnabled(LogLevel) bool
 }
+
+// FieldLogger is a Logger, that can derive loggers, which print
+// key/value pairs with every message.
+// All loggers of this package are FieldLoggers.
+type FieldLogger interface {
+	Logger
+
+	// With returns a new logger that prints the given key/value pairs
+	// with every message, in addition to the fields of this logger.
+	// The arguments are alternating keys and values, e.g.
+	//
+	//	logger.With("request", id, "user", name)
+	//
+	// The returned logger starts with the configuration of this logger,
+	// but changing the configuration of one of them does not affect the other.
+	// With on a WriterLogger of this package returns a WriterLogger.
+	With(...interface{}) Logger
+}
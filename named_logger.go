@@ -1,9 +1,7 @@
 package abc
 
 import (
-	"fmt"
-	"io"
-	"sync"
+	"bytes"
 )
 
 const (
@@ -12,172 +10,114 @@ const (
 	TimeLayoutNamedLogger = "2006-01-02 15:04:05.000"
 )
 
+var namedTimestamps = &timestampCache{layout: TimeLayoutNamedLogger}
+
 // NamedLogger is a logger that has and prints a name
 // in its log messages.
 // NamedLoggers are completely safe for concurrent use.
 type NamedLogger struct {
-	lvlMux sync.Mutex
-	lvl    LogLevel
-
-	clockMux sync.Mutex
-	clk      clock
-
-	outMux sync.Mutex
-	out    io.Writer
-
-	nameMux sync.Mutex
-	name    string
-}
-
-// Print prints the given values with the given log level,
-// if and only if the given log level is higher than or
-// equal to the one of this logger.
-func (l *NamedLogger) Print(lvl LogLevel, v ...interface{}) {
-	if l.IsLevelEnabled(lvl) {
-		l.print0(l.prepareMessage(lvl, fmt.Sprint(v...)))
-	}
-}
-
-// Printf formats and prints the given values with the given log level,
-// if and only if the given log level is higher than or
-// equal to the one of this logger.
-func (l *NamedLogger) Printf(lvl LogLevel, format string, v ...interface{}) {
-	if l.IsLevelEnabled(lvl) {
-		l.print0(l.prepareMessage(lvl, fmt.Sprintf(format, v...)))
-	}
-}
-
-func (l *NamedLogger) prepareMessage(lvl LogLevel, a string) string {
-	return fmt.Sprintf("%v <%-v> [%-4v] - %v\n", l.clk.Now().Format(TimeLayoutNamedLogger), l.name, lvl.String(), a)
-}
-
-func (l *NamedLogger) print0(a string) {
-	io.WriteString(l.out, a)
-}
-
-// Verbose prints the given values with log level DEBG,
-// if and only if this logger has the verbose log level enabled.
-func (l *NamedLogger) Verbose(v ...interface{}) {
-	l.Print(LevelVerbose, v...)
-}
-
-// Verbosef formats and prints the given values with log level DEBG,
-// if and only if this logger has the verbose log level enabled.
-func (l *NamedLogger) Verbosef(format string, v ...interface{}) {
-	l.Printf(LevelVerbose, format, v...)
-}
-
-// Debug prints the given values with log level DEBG.
-func (l *NamedLogger) Debug(v ...interface{}) {
-	l.Print(LevelDebug, v...)
-}
-
-// Debugf formats and prints the given values with log level DEBG.
-func (l *NamedLogger) Debugf(format string, v ...interface{}) {
-	l.Printf(LevelDebug, format, v...)
-}
-
-// Info prints the given values with log level INFO.
-func (l *NamedLogger) Info(v ...interface{}) {
-	l.Print(LevelInfo, v...)
-}
-
-// Infof formats and prints the given values with log level INFO.
-func (l *NamedLogger) Infof(format string, v ...interface{}) {
-	l.Printf(LevelInfo, format, v...)
-}
-
-// Warn prints the given values with log level WARN.
-func (l *NamedLogger) Warn(v ...interface{}) {
-	l.Print(LevelWarn, v...)
-}
-
-// Warnf formats and prints the given values with log level WARN.
-func (l *NamedLogger) Warnf(format string, v ...interface{}) {
-	l.Printf(LevelWarn, format, v...)
-}
-
-// Error prints the given values with log level ERR.
-func (l *NamedLogger) Error(v ...interface{}) {
-	l.Print(LevelError, v...)
-}
-
-// Errorf formats and prints the given values with log level ERR.
-func (l *NamedLogger) Errorf(format string, v ...interface{}) {
-	l.Printf(LevelError, format, v...)
+	writerCore
+
+	// node is the node of the logger in its hierarchy, or nil if
+	// the logger is not part of a hierarchy, see Child.
+	// It is guarded by hierarchyMux.
+	node *loggerNode
+}
+
+// namedFormatter is the formatter of the NamedLogger.
+type namedFormatter struct {
+	caller CallerMode
+}
+
+func (f namedFormatter) Format(buf *bytes.Buffer, rec *Record) error {
+	return f.formatColored(buf, rec, nil)
+}
+
+// formatColored colors the name and the level.
+func (f namedFormatter) formatColored(buf *bytes.Buffer, rec *Record, colors []color) error {
+	namedTimestamps.write(buf, rec.Time)
+	buf.WriteByte(' ')
+	startColor(buf, colors, rec.Level)
+	buf.WriteByte('<')
+	buf.WriteString(rec.Name)
+	buf.WriteByte('>')
+	endColor(buf, colors)
+	buf.WriteByte(' ')
+	startColor(buf, colors, rec.Level)
+	buf.WriteByte('[')
+	writePaddedLevel(buf, rec.Level)
+	buf.WriteByte(']')
+	endColor(buf, colors)
+	writeCaller(buf, f.caller, rec)
+	buf.WriteString(" - ")
+	buf.WriteString(rec.Message)
+	rec.Fields.writeSuffix(buf)
+	buf.WriteByte('\n')
+	return nil
+}
+
+// With returns a new NamedLogger that prints the given key/value
+// pairs after the message of every log line, in addition to the
+// fields of this logger.
+// The new logger starts with the level, clock, writer and name of this logger,
+// but is not part of the hierarchy of this logger, see Child.
+func (l *NamedLogger) With(keyvals ...interface{}) Logger {
+	derived := &NamedLogger{}
+	l.derive(&derived.writerCore, keyvals...)
+	return derived
 }
 
-// Fatal prints the given values with log level FATAL.
-// IT DOES NOT TERMINATE THE APPLICATION.
-func (l *NamedLogger) Fatal(v ...interface{}) {
-	l.Print(LevelFatal, v...)
-}
-
-// Fatalf formats and prints the given values with log level FATAL.
-// IT DOES NOT TERMINATE THE APPLICATION.
-func (l *NamedLogger) Fatalf(format string, v ...interface{}) {
-	l.Printf(LevelFatal, format, v...)
-}
-
-// Level returns the current level of this logger.
-func (l *NamedLogger) Level() LogLevel {
-	l.lvlMux.Lock()
-	defer l.lvlMux.Unlock()
-
-	return l.lvl
-}
-
-// SetLevel changes the log level of this logger.
-func (l *NamedLogger) SetLevel(lvl LogLevel) {
-	l.lvlMux.Lock()
-	defer l.lvlMux.Unlock()
-
-	l.lvl = lvl
-}
-
-func (s *NamedLogger) SetLevelString(level string) {
-	s.SetLevel(ToLogLevel(level))
+// Name returns the name of this logger.
+func (l *NamedLogger) Name() string {
+	return l.getName()
 }
 
-// IsLevelEnabled returns true if and only if this logger would print
-// messages with the given log level.
-// False otherwise.
-func (l *NamedLogger) IsLevelEnabled(lvl LogLevel) bool {
-	return lvl >= l.Level()
+// SetName sets a new name for this logger.
+// The names of its children in the hierarchy are not changed.
+func (l *NamedLogger) SetName(name string) {
+	l.setName(name)
 }
 
-// clock returns the clock of this logger.
-func (l *NamedLogger) clock() clock {
-	return l.clk
+// SetCallerMode sets the way in which this logger prints the caller
+// of every message, between the level and the message, e.g.
+//
+//	2018-11-24 15:26:44.453 <db> [INFO] main.go:16 - Hello World!
+//
+// The caller is not printed with CallerNone, which is the default.
+func (l *NamedLogger) SetCallerMode(mode CallerMode) {
+	l.setFormatter(namedFormatter{caller: mode})
+	l.setRecordsCaller(mode != CallerNone)
 }
 
-// SetClock sets a new clock for this logger.
-func (l *NamedLogger) SetClock(clk clock) {
-	l.clockMux.Lock()
-	defer l.clockMux.Unlock()
-	l.clk = clk
+// ColorMode returns the color mode of this logger.
+func (l *NamedLogger) ColorMode() ColorMode {
+	return l.getColorMode()
 }
 
-// Out returns the writer of this logger.
-func (l *NamedLogger) Out() io.Writer {
-	return l.out
+// SetColorMode sets the color mode of this logger, which controls
+// whether the name and the level are printed in the color of the
+// level, e.g. with ColorModeAuto, only if the writer is a terminal.
+// The color mode is described at ColoredLogger, which colors
+// whole lines instead.
+// No colors are printed with ColorModeNever, which is the default.
+func (l *NamedLogger) SetColorMode(mode ColorMode) {
+	l.setColorMode(mode)
 }
 
-// SetOut sets a new writer for this logger.
-func (l *NamedLogger) SetOut(out io.Writer) {
-	l.outMux.Lock()
-	defer l.outMux.Unlock()
-	l.out = out
+// Theme returns a copy of the color theme of this logger.
+func (l *NamedLogger) Theme() ColorTheme {
+	return l.getTheme()
 }
 
-// Name returns the name of this logger.
-func (l *NamedLogger) Name() string {
-	return l.name
+// SetTheme sets the color theme, with which the name and the
+// level are colored.
+// The theme is copied, so later changes of the given theme
+// have no effect.
+func (l *NamedLogger) SetTheme(theme ColorTheme) {
+	l.setTheme(theme)
 }
 
-// SetName sets a new name for this logger.
-func (l *NamedLogger) SetName(name string) {
-	l.nameMux.Lock()
-	defer l.nameMux.Unlock()
-	l.name = name
+// ColorsEnabled returns whether colors are currently printed.
+func (l *NamedLogger) ColorsEnabled() bool {
+	return l.colorsEnabled()
 }
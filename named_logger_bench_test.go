@@ -5,12 +5,7 @@ import (
 )
 
 func BenchmarkNamedLogger_Printf(b *testing.B) {
-	logger := &NamedLogger{
-		clk:  &mockClock{},
-		lvl:  LevelVerbose,
-		out:  &MockWriter{},
-		name: "MyLogger",
-	}
+	logger := newTestNamedLogger("MyLogger", LevelVerbose, &MockWriter{})
 	b.ReportAllocs()
 	b.ResetTimer()
 	b.RunParallel(func(pb *testing.PB) {
@@ -2,6 +2,7 @@ package abc
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
 
 	"github.com/sergi/go-diff/diffmatchpatch"
@@ -11,12 +12,7 @@ import (
 func TestNamedLogger_Printf(t *testing.T) {
 	buf := &bytes.Buffer{}
 
-	logger := &NamedLogger{
-		clk:  &mockClock{},
-		lvl:  LevelDebug,
-		out:  buf,
-		name: "MyLogger",
-	}
+	logger := newTestNamedLogger("MyLogger", LevelDebug, buf)
 
 	type args struct {
 		lvl    LogLevel
@@ -81,12 +77,7 @@ func TestNamedLogger_Printf(t *testing.T) {
 func TestNamedLogger_Print(t *testing.T) {
 	buf := &bytes.Buffer{}
 
-	logger := &NamedLogger{
-		clk:  &mockClock{},
-		lvl:  LevelDebug,
-		out:  buf,
-		name: "MyLogger",
-	}
+	logger := newTestNamedLogger("MyLogger", LevelDebug, buf)
 
 	type args struct {
 		lvl LogLevel
@@ -187,12 +178,7 @@ func TestNamedLogger_All_Outputs(t *testing.T) {
 
 	buf := &bytes.Buffer{}
 
-	logger := &NamedLogger{
-		clk:  &mockClock{},
-		lvl:  LevelVerbose,
-		out:  buf,
-		name: "MyLogger",
-	}
+	logger := newTestNamedLogger("MyLogger", LevelVerbose, buf)
 
 	check := func() {
 		defer func() {
@@ -249,12 +235,7 @@ func TestNamedLogger_SetOut(t *testing.T) {
 	buf1 := &bytes.Buffer{}
 	buf2 := &bytes.Buffer{}
 
-	logger := &NamedLogger{
-		clk:  &mockClock{},
-		lvl:  LevelVerbose,
-		out:  buf1,
-		name: "MyLogger",
-	}
+	logger := newTestNamedLogger("MyLogger", LevelVerbose, buf1)
 
 	logger.Info("foo")
 	assert.Equal("0001-01-01 00:00:00.000 <MyLogger> [INFO] - foo\n", buf1.String(), "buf1 did receive wrong output.")
@@ -284,12 +265,7 @@ func TestNamedLogger_SetLevel(t *testing.T) {
 
 	buf := &bytes.Buffer{}
 
-	logger := &NamedLogger{
-		clk:  &mockClock{},
-		lvl:  LevelVerbose,
-		out:  buf,
-		name: "MyLogger",
-	}
+	logger := newTestNamedLogger("MyLogger", LevelVerbose, buf)
 
 	logger.Verbose("foo")
 	assert.Equal("0001-01-01 00:00:00.000 <MyLogger> [DEBG] - foo\n", buf.String(), "buf did receive wrong output.")
@@ -300,3 +276,55 @@ func TestNamedLogger_SetLevel(t *testing.T) {
 	logger.Verbose("foo")
 	assert.Equal("", buf.String(), "buf did receive output.")
 }
+
+func TestNamedLogger_With(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	logger := newTestNamedLogger("MyLogger", LevelVerbose, buf)
+
+	derived := logger.With("request", 17)
+	derived.Info("abc")
+	assert.Equal("0001-01-01 00:00:00.000 <MyLogger> [INFO] - abc request=17\n", buf.String(), "buf did receive wrong output.")
+
+	buf.Reset()
+
+	logger.Info("abc")
+	assert.Equal("0001-01-01 00:00:00.000 <MyLogger> [INFO] - abc\n", buf.String(), "buf did receive wrong output.")
+}
+
+func TestNamedLogger_SetCallerMode(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	logger := newTestNamedLogger("db", LevelInfo, buf)
+
+	logger.SetCallerMode(CallerFile)
+	line := nextLine()
+	logThroughWrapper(AddCallerSkip(logger, 1), "file")
+	logger.SetCallerMode(CallerFunction)
+	logger.Info("function")
+
+	assert.Equal(""+
+		fmt.Sprintf("0001-01-01 00:00:00.000 <db> [INFO] named_logger_test.go:%v - file\n", line)+
+		"0001-01-01 00:00:00.000 <db> [INFO] abc.TestNamedLogger_SetCallerMode - function\n", buf.String())
+}
+
+func TestNamedLogger_SetColorMode(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	logger := newTestNamedLogger("MyLogger", LevelInfo, buf)
+	assert.Equal(ColorModeNever, logger.ColorMode(), "Colors must be disabled by default")
+
+	logger.SetColorMode(ColorModeAlways)
+	logger.Error("abc")
+	assert.Equal("0001-01-01 00:00:00.000 "+string(ColorRed)+"<MyLogger>"+string(ColorReset)+" "+string(ColorRed)+"[ERR ]"+string(ColorReset)+" - abc\n", buf.String())
+	assert.True(logger.ColorsEnabled())
+
+	buf.Reset()
+	logger.SetColorMode(ColorModeNever)
+	logger.Error("abc")
+	assert.Equal("0001-01-01 00:00:00.000 <MyLogger> [ERR ] - abc\n", buf.String())
+}
@@ -0,0 +1,678 @@
+package abc
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PatternError is the error that is returned for an invalid pattern.
+// It contains the position of the error in the pattern.
+type PatternError struct {
+	// Pattern is the invalid pattern.
+	Pattern string
+	// Offset is the byte offset of the error in the pattern.
+	Offset int
+	// Line and Column are the position of the error in the pattern,
+	// starting at 1. The column is counted in runes.
+	Line, Column int
+	// Msg describes the error.
+	Msg string
+}
+
+func newPatternError(pattern string, offset int, format string, v ...interface{}) *PatternError {
+	before := pattern[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := utf8.RuneCountInString(before[strings.LastIndexByte(before, '\n')+1:]) + 1
+	return &PatternError{
+		Pattern: pattern,
+		Offset:  offset,
+		Line:    line,
+		Column:  column,
+		Msg:     fmt.Sprintf(format, v...),
+	}
+}
+
+func (e *PatternError) Error() string {
+	return fmt.Sprintf("pattern:%v:%v: %v", e.Line, e.Column, e.Msg)
+}
+
+// patternSegment writes one part of a record, e.g. a fixed text
+// or the level, to the given buffer.
+type patternSegment func(buf *bytes.Buffer, ctx *patternContext)
+
+// patternContext is the state of a single execution of a pattern.
+type patternContext struct {
+	rec *Record
+	// field is the current field inside of {{range .Fields}}.
+	field *Field
+
+	callerResolved bool
+	file           string
+	line           int
+	function       string
+
+	// counter is the counter of {{.Seq}}, which belongs to the logger,
+	// or nil if the sequence number is always 0.
+	counter     *uint64
+	seqResolved bool
+	seq         uint64
+
+	// colors are the escape codes of the levels, or nil
+	// if no colors are written.
+	colors []color
+
+	// err is the first error, that occurred while executing the pattern.
+	err error
+}
+
+// fail records the given error, unless an error was recorded before.
+func (c *patternContext) fail(err error) {
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+var patternContextPool = sync.Pool{
+	New: func() interface{} {
+		return &patternContext{}
+	},
+}
+
+// resolveCaller resolves the caller of the record, when it is
+// needed for the first time.
+// The caller is resolved like in Record.Caller, but without
+// allocations.
+func (c *patternContext) resolveCaller() {
+	if c.callerResolved {
+		return
+	}
+	c.callerResolved = true
+
+	if c.rec.PC == 0 {
+		return
+	}
+	// the PC is a return address, like in Record.Caller
+	if fn := runtime.FuncForPC(c.rec.PC - 1); fn != nil {
+		c.file, c.line = fn.FileLine(c.rec.PC - 1)
+		c.function = fn.Name()
+	}
+}
+
+// compiledPattern is a pattern, that was compiled into a list of
+// segments, which are executed in order for every record.
+type compiledPattern struct {
+	source   string
+	segments []patternSegment
+	// needsCaller is whether the pattern prints the file, line
+	// or function of the caller, which must then be recorded.
+	needsCaller bool
+}
+
+// execute writes the given record, formatted according to this
+// pattern, to the given buffer.
+// If a function of the pattern fails, the error is written to the
+// buffer instead of the output of the function, and the execution
+// continues. The first of these errors is returned.
+func (p *compiledPattern) execute(buf *bytes.Buffer, rec *Record) error {
+	return p.executeColored(buf, rec, nil, nil)
+}
+
+// executeColored executes the pattern like execute, while the
+// color function colors with the given escape codes of the levels,
+// unless they are nil, and the sequence number is taken from the
+// given counter.
+func (p *compiledPattern) executeColored(buf *bytes.Buffer, rec *Record, colors []color, counter *uint64) error {
+	ctx := patternContextPool.Get().(*patternContext)
+	ctx.rec = rec
+	ctx.colors = colors
+	ctx.counter = counter
+	for _, segment := range p.segments {
+		segment(buf, ctx)
+	}
+	err := ctx.err
+	*ctx = patternContext{}
+	patternContextPool.Put(ctx)
+	return err
+}
+
+// compileTemplatePattern compiles a pattern in the go template
+// syntax, that is described in the documentation of CustomPatternLogger.
+// The given functions can be called in the pattern in addition
+// to the builtin functions.
+// Patterns that use constructs of go templates, which are not
+// compiled into segments, like {{if}} or variables, are executed
+// with text/template.
+// Errors are of type *PatternError.
+func compileTemplatePattern(pattern string, funcs template.FuncMap) (*compiledPattern, error) {
+	p := &templateParser{src: pattern, funcs: funcs}
+	segments, err := p.parseList(-1)
+	if err != nil {
+		if p.unsupported {
+			return p.compileTextTemplate(err.(*PatternError))
+		}
+		return nil, err
+	}
+	return &compiledPattern{
+		source:      pattern,
+		segments:    segments,
+		needsCaller: p.needsCaller,
+	}, nil
+}
+
+// templateParser parses patterns in the go template syntax.
+type templateParser struct {
+	src   string
+	pos   int
+	funcs template.FuncMap
+	// needsCaller is set, when a verb of the caller is used.
+	needsCaller bool
+	// trimNext indicates that the leading whitespace of the next
+	// text must be trimmed, because the previous action ended with " -}}".
+	trimNext bool
+	// unsupported is set, when the parser failed at a construct of
+	// go templates, that it doesn't support, so the pattern must be
+	// executed with text/template.
+	unsupported bool
+}
+
+// patternToken is a token of an action.
+type patternToken struct {
+	kind patternTokenKind
+	pos  int
+	// val is the name of a field or identifier,
+	// or the value of a string or number.
+	val string
+}
+
+type patternTokenKind uint8
+
+const (
+	tokenField  patternTokenKind = iota // .Name
+	tokenIdent                          // name
+	tokenString                         // "value" or `value`
+	tokenNumber                         // 17
+	tokenPipe                           // |
+)
+
+// parseList parses texts and actions until {{end}} or the end of the
+// pattern.
+// rangeStart is the offset of the enclosing {{range}}, or -1 if the
+// list is not in a range.
+func (p *templateParser) parseList(rangeStart int) ([]patternSegment, error) {
+	var segments []patternSegment
+	for {
+		i := strings.Index(p.src[p.pos:], "{{")
+		if i < 0 {
+			segments = p.appendText(segments, p.src[p.pos:])
+			p.pos = len(p.src)
+			if rangeStart >= 0 {
+				return nil, newPatternError(p.src, rangeStart, "{{range}} is not closed with {{end}}")
+			}
+			return segments, nil
+		}
+
+		text := p.src[p.pos : p.pos+i]
+		start := p.pos + i
+		p.pos = start + 2
+		if strings.HasPrefix(p.src[p.pos:], "-") && len(p.src) > p.pos+1 && isPatternSpace(p.src[p.pos+1]) {
+			text = strings.TrimRightFunc(text, unicode.IsSpace)
+			p.pos++
+		}
+		segments = p.appendText(segments, text)
+
+		tokens, err := p.lexAction(start)
+		if err != nil {
+			return nil, err
+		}
+		if tokens == nil {
+			// comment
+			continue
+		}
+
+		first := tokens[0]
+		if first.kind == tokenIdent {
+			switch first.val {
+			case "range":
+				if rangeStart >= 0 {
+					return nil, newPatternError(p.src, first.pos, "nested {{range}} is not supported")
+				}
+				if len(tokens) != 2 || tokens[1].kind != tokenField || tokens[1].val != "Fields" {
+					p.unsupported = true
+					return nil, newPatternError(p.src, first.pos, "only {{range .Fields}} is supported")
+				}
+				body, err := p.parseList(start)
+				if err != nil {
+					return nil, err
+				}
+				segments = append(segments, rangeFieldsSegment(body))
+				continue
+			case "end":
+				if rangeStart < 0 {
+					return nil, newPatternError(p.src, first.pos, "unexpected {{end}}")
+				}
+				if len(tokens) != 1 {
+					return nil, newPatternError(p.src, tokens[1].pos, "unexpected %v in {{end}}", p.describe(tokens[1]))
+				}
+				return segments, nil
+			}
+		}
+
+		segment, err := p.compileAction(tokens, rangeStart >= 0)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+}
+
+// appendText appends a segment that writes the given text,
+// if it is not empty.
+func (p *templateParser) appendText(segments []patternSegment, text string) []patternSegment {
+	if p.trimNext {
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+		p.trimNext = false
+	}
+	if text == "" {
+		return segments
+	}
+	return append(segments, func(buf *bytes.Buffer, _ *patternContext) {
+		buf.WriteString(text)
+	})
+}
+
+// lexAction splits the action at the current position into tokens,
+// until the closing braces, which are consumed.
+// It returns nil tokens for a comment.
+// start is the offset of the opening braces.
+func (p *templateParser) lexAction(start int) ([]patternToken, error) {
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.pos:], "/*") {
+		end := strings.Index(p.src[p.pos:], "*/")
+		if end < 0 {
+			return nil, newPatternError(p.src, p.pos, "comment is not closed")
+		}
+		p.pos += end + 2
+		if err := p.closeAction(start); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	var tokens []patternToken
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return nil, newPatternError(p.src, start, "action is not closed with }}")
+		}
+		if strings.HasPrefix(p.src[p.pos:], "}}") || strings.HasPrefix(p.src[p.pos:], "-}}") {
+			if len(tokens) == 0 {
+				return nil, newPatternError(p.src, start, "empty action")
+			}
+			return tokens, p.closeAction(start)
+		}
+
+		tokenStart := p.pos
+		c := p.src[p.pos]
+		switch {
+		case c == '|':
+			p.pos++
+			tokens = append(tokens, patternToken{tokenPipe, tokenStart, "|"})
+		case c == '.':
+			p.pos++
+			name := p.scanIdent()
+			if name == "" {
+				p.unsupported = true
+				return nil, newPatternError(p.src, tokenStart, "expected a name after '.'")
+			}
+			tokens = append(tokens, patternToken{tokenField, tokenStart, name})
+		case c == '"' || c == '`':
+			s, err := p.scanString()
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, patternToken{tokenString, tokenStart, s})
+		case c == '-' || (c >= '0' && c <= '9'):
+			p.pos++
+			for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+				p.pos++
+			}
+			number := p.src[tokenStart:p.pos]
+			if number == "-" {
+				return nil, newPatternError(p.src, tokenStart, "unexpected '-'")
+			}
+			tokens = append(tokens, patternToken{tokenNumber, tokenStart, number})
+		case c == '_' || unicode.IsLetter(rune(c)):
+			tokens = append(tokens, patternToken{tokenIdent, tokenStart, p.scanIdent()})
+		default:
+			// e.g. a variable or parentheses
+			p.unsupported = true
+			r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+			return nil, newPatternError(p.src, tokenStart, "unexpected %q in action", r)
+		}
+	}
+}
+
+// closeAction consumes the closing braces of an action,
+// including a trim marker.
+func (p *templateParser) closeAction(start int) error {
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.pos:], "-}}") && p.pos > 0 && isPatternSpace(p.src[p.pos-1]) {
+		p.trimNext = true
+		p.pos++
+	}
+	if !strings.HasPrefix(p.src[p.pos:], "}}") {
+		if p.pos >= len(p.src) {
+			return newPatternError(p.src, start, "action is not closed with }}")
+		}
+		return newPatternError(p.src, p.pos, "expected }}")
+	}
+	p.pos += 2
+	return nil
+}
+
+func (p *templateParser) skipSpace() {
+	for p.pos < len(p.src) && isPatternSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *templateParser) scanIdent() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			break
+		}
+		p.pos += size
+	}
+	return p.src[start:p.pos]
+}
+
+// scanString scans a quoted or raw string at the current position
+// and returns its value.
+func (p *templateParser) scanString() (string, error) {
+	start := p.pos
+	quote := p.src[p.pos]
+	p.pos++
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == '\\' && quote == '"':
+			p.pos += 2
+			continue
+		case c == '\n' && quote == '"':
+			return "", newPatternError(p.src, start, "string is not terminated")
+		case c == quote:
+			p.pos++
+			s, err := strconv.Unquote(p.src[start:p.pos])
+			if err != nil {
+				return "", newPatternError(p.src, start, "invalid string: %v", err)
+			}
+			return s, nil
+		}
+		p.pos++
+	}
+	return "", newPatternError(p.src, start, "string is not terminated")
+}
+
+func isPatternSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// describe returns a description of the given token for error messages.
+func (p *templateParser) describe(t patternToken) string {
+	switch t.kind {
+	case tokenField:
+		return "." + t.val
+	case tokenString:
+		return strconv.Quote(t.val)
+	}
+	return t.val
+}
+
+// compileAction compiles the tokens of an action, which is not
+// a control structure, into a segment.
+// The action is a pipeline of commands, that are separated by '|'.
+// The output of every command is passed to the next command
+// as last argument.
+func (p *templateParser) compileAction(tokens []patternToken, inRange bool) (patternSegment, error) {
+	var commands [][]patternToken
+	start := 0
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) && tokens[i].kind != tokenPipe {
+			continue
+		}
+		if i == start {
+			pos := tokens[len(tokens)-1].pos
+			if i < len(tokens) {
+				pos = tokens[i].pos
+			}
+			return nil, newPatternError(p.src, pos, "missing command in pipeline")
+		}
+		commands = append(commands, tokens[start:i])
+		start = i + 1
+	}
+
+	segment, err := p.compileCommand(commands[0], inRange)
+	if err != nil {
+		return nil, err
+	}
+	if len(commands) == 1 {
+		return segment, nil
+	}
+
+	filters := make([]patternFilter, 0, len(commands)-1)
+	for _, command := range commands[1:] {
+		if command[0].kind != tokenIdent {
+			return nil, newPatternError(p.src, command[0].pos, "unexpected %v, expected a function after '|'", p.describe(command[0]))
+		}
+		_, filter, err := p.compileFunction(command[0], command[1:], inRange, true)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+	return pipelineSegment(segment, filters), nil
+}
+
+// compileCommand compiles the first command of a pipeline, which
+// is either a verb or a function call, into a segment.
+func (p *templateParser) compileCommand(tokens []patternToken, inRange bool) (patternSegment, error) {
+	verb := tokens[0]
+	switch verb.kind {
+	case tokenField:
+	case tokenIdent:
+		input, filter, err := p.compileFunction(verb, tokens[1:], inRange, false)
+		if err != nil {
+			return nil, err
+		}
+		return pipelineSegment(input, []patternFilter{filter}), nil
+	default:
+		return nil, newPatternError(p.src, verb.pos, "unexpected %v, expected a verb like .Message", p.describe(verb))
+	}
+
+	args := tokens[1:]
+	for _, arg := range args {
+		if arg.kind != tokenString {
+			return nil, newPatternError(p.src, arg.pos, "argument of .%v must be a string, found %v", verb.val, p.describe(arg))
+		}
+	}
+
+	segment, wantArgs, ok := p.verb(verb.val, args, inRange)
+	if !ok {
+		if inRange {
+			return nil, newPatternError(p.src, verb.pos, "unknown verb .%v, only .Key and .Value are available in {{range .Fields}}", verb.val)
+		}
+		return nil, newPatternError(p.src, verb.pos, "unknown verb .%v", verb.val)
+	}
+	if len(args) != wantArgs {
+		pos := verb.pos
+		if len(args) > wantArgs {
+			pos = args[wantArgs].pos
+		}
+		return nil, newPatternError(p.src, pos, "wrong number of arguments for .%v: want %v, got %v", verb.val, wantArgs, len(args))
+	}
+	return segment, nil
+}
+
+// verb returns the segment of the verb with the given name
+// and the number of arguments, that the verb takes.
+// If there is no such verb, false is returned.
+// If the number of arguments is wrong, the segment is nil.
+func (p *templateParser) verb(name string, args []patternToken, inRange bool) (patternSegment, int, bool) {
+	arg := func() string {
+		if len(args) == 0 {
+			return ""
+		}
+		return args[0].val
+	}
+
+	if inRange {
+		switch name {
+		case "Key":
+			return writeFieldKey, 0, true
+		case "Value":
+			return writeFieldValue, 0, true
+		}
+		return nil, 0, false
+	}
+
+	switch name {
+	case "File", "Filef", "Line", "Function", "Functionf":
+		p.needsCaller = true
+	}
+
+	switch name {
+	case "Level":
+		return writeLevel, 0, true
+	case "Message":
+		return writeMessage, 0, true
+	case "Fields":
+		return writeFields, 0, true
+	case "Timestamp":
+		timestamps := &timestampCache{layout: TimeLayoutCustomPatternLogger}
+		return func(buf *bytes.Buffer, ctx *patternContext) {
+			timestamps.write(buf, ctx.rec.Time)
+		}, 0, true
+	case "Timestampf":
+		return timestampSegment(arg()), 1, true
+	case "File":
+		return fileSegment("short"), 0, true
+	case "Filef":
+		return fileSegment(arg()), 1, true
+	case "Line":
+		return writeLine, 0, true
+	case "Function":
+		return functionSegment("package"), 0, true
+	case "Functionf":
+		return functionSegment(arg()), 1, true
+	case "Name":
+		return writeName, 0, true
+	case "Field":
+		return fieldSegment(arg()), 1, true
+	case "Hostname":
+		return writeHostname, 0, true
+	case "PID":
+		return writePID, 0, true
+	case "GoroutineID":
+		return writeGoroutineID, 0, true
+	case "Seq":
+		return writeSeq, 0, true
+	case "Elapsed":
+		return writeElapsed, 0, true
+	}
+	return nil, 0, false
+}
+
+// writeLevel writes the level, padded to at least 4 characters.
+func writeLevel(buf *bytes.Buffer, ctx *patternContext) {
+	writePaddedLevel(buf, ctx.rec.Level)
+}
+
+func writeMessage(buf *bytes.Buffer, ctx *patternContext) {
+	buf.WriteString(ctx.rec.Message)
+}
+
+func writeFields(buf *bytes.Buffer, ctx *patternContext) {
+	buf.WriteString(ctx.rec.Fields.String())
+}
+
+func writeLine(buf *bytes.Buffer, ctx *patternContext) {
+	ctx.resolveCaller()
+	buf.Grow(20)
+	buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(ctx.line), 10))
+}
+
+func writeFieldKey(buf *bytes.Buffer, ctx *patternContext) {
+	buf.WriteString(ctx.field.Key)
+}
+
+func writeFieldValue(buf *bytes.Buffer, ctx *patternContext) {
+	if s, ok := ctx.field.Value.(string); ok {
+		buf.WriteString(s)
+		return
+	}
+	fmt.Fprint(buf, ctx.field.Value)
+}
+
+func timestampSegment(layout string) patternSegment {
+	return func(buf *bytes.Buffer, ctx *patternContext) {
+		// the formatted time is usually not much longer than the layout
+		buf.Grow(len(layout) + 16)
+		buf.Write(ctx.rec.Time.AppendFormat(buf.AvailableBuffer(), layout))
+	}
+}
+
+// fileSegment returns a segment that writes the file of the caller.
+// The mode "short" writes only the file name, every other mode
+// writes the full path.
+func fileSegment(mode string) patternSegment {
+	return func(buf *bytes.Buffer, ctx *patternContext) {
+		ctx.resolveCaller()
+		if mode == "short" {
+			buf.WriteString(filepath.Base(ctx.file))
+			return
+		}
+		buf.WriteString(ctx.file)
+	}
+}
+
+// functionSegment returns a segment that writes the function of
+// the caller.
+// The mode "short" writes only the function name, "package" writes
+// <package>.<function> and every other mode writes the full name.
+func functionSegment(mode string) patternSegment {
+	return func(buf *bytes.Buffer, ctx *patternContext) {
+		ctx.resolveCaller()
+		name := ctx.function
+		switch mode {
+		case "short":
+			buf.WriteString(name[strings.LastIndex(name, ".")+1:])
+		case "package":
+			buf.WriteString(filepath.Base(name))
+		default:
+			buf.WriteString(name)
+		}
+	}
+}
+
+// rangeFieldsSegment returns a segment that executes the given body
+// for every field of the record.
+func rangeFieldsSegment(body []patternSegment) patternSegment {
+	return func(buf *bytes.Buffer, ctx *patternContext) {
+		for i := range ctx.rec.Fields {
+			ctx.field = &ctx.rec.Fields[i]
+			for _, segment := range body {
+				segment(buf, ctx)
+			}
+		}
+		ctx.field = nil
+	}
+}
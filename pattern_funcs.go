@@ -0,0 +1,393 @@
+package abc
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// patternFilter transforms everything that was written to the buffer
+// after start, which is the output of the previous command of a pipeline.
+type patternFilter func(buf *bytes.Buffer, start int, ctx *patternContext)
+
+// pipelineSegment returns a segment that executes the given
+// segment and passes its output through all given filters.
+func pipelineSegment(segment patternSegment, filters []patternFilter) patternSegment {
+	return func(buf *bytes.Buffer, ctx *patternContext) {
+		start := buf.Len()
+		segment(buf, ctx)
+		for _, filter := range filters {
+			filter(buf, start, ctx)
+		}
+	}
+}
+
+// patternBuiltin is a function that is available in every template
+// pattern. All arguments of a builtin are numbers.
+type patternBuiltin struct {
+	args   int
+	filter func(args []int) patternFilter
+}
+
+var patternBuiltins = map[string]patternBuiltin{
+	"pad": {1, func(args []int) patternFilter {
+		width := args[0]
+		return func(buf *bytes.Buffer, start int, _ *patternContext) {
+			if width < 0 {
+				alignOutput(buf, start, -width, true, -1, false)
+				return
+			}
+			alignOutput(buf, start, width, false, -1, false)
+		}
+	}},
+	"trunc": {1, func(args []int) patternFilter {
+		max := args[0]
+		return func(buf *bytes.Buffer, start int, _ *patternContext) {
+			if max < 0 {
+				alignOutput(buf, start, 0, false, -max, false)
+				return
+			}
+			alignOutput(buf, start, 0, false, max, true)
+		}
+	}},
+	"upper": {0, func([]int) patternFilter {
+		return caseFilter(unicode.ToUpper)
+	}},
+	"lower": {0, func([]int) patternFilter {
+		return caseFilter(unicode.ToLower)
+	}},
+	"color": {0, func([]int) patternFilter {
+		return colorFilter
+	}},
+}
+
+// colorFilter surrounds the output with the escape code of the
+// level of the record and the color reset, if colors are written.
+func colorFilter(buf *bytes.Buffer, start int, ctx *patternContext) {
+	if ctx.colors == nil {
+		return
+	}
+	code := levelCode(ctx.colors, ctx.rec.Level)
+	end := buf.Len()
+	buf.Write(code)
+	out := buf.Bytes()[start:]
+	copy(out[len(code):], out[:end-start])
+	copy(out, code)
+	buf.Write(ColorReset)
+}
+
+// caseFilter returns a filter that maps every rune of the output
+// with the given function.
+// ASCII output is mapped in place.
+func caseFilter(mapping func(rune) rune) patternFilter {
+	return func(buf *bytes.Buffer, start int, _ *patternContext) {
+		out := buf.Bytes()[start:]
+		for i, c := range out {
+			if c >= utf8.RuneSelf {
+				mapped := bytes.Map(mapping, out[i:])
+				buf.Truncate(start + i)
+				buf.Write(mapped)
+				return
+			}
+			out[i] = byte(mapping(rune(c)))
+		}
+	}
+}
+
+// compileFunction compiles the call of the function fn with the given
+// arguments.
+// If piped is true, the output of the previous command of the pipeline
+// is the last argument, and the returned segment is nil.
+// Otherwise, the returned segment writes the last argument of a builtin,
+// which is then transformed by the filter.
+func (p *templateParser) compileFunction(fn patternToken, args []patternToken, inRange, piped bool) (patternSegment, patternFilter, error) {
+	if f, ok := p.funcs[fn.val]; ok {
+		filter, err := p.compileFuncCall(fn, f, args, inRange, piped)
+		if err != nil {
+			return nil, nil, err
+		}
+		if piped {
+			return nil, filter, nil
+		}
+		return func(*bytes.Buffer, *patternContext) {}, filter, nil
+	}
+
+	builtin, ok := patternBuiltins[fn.val]
+	if !ok {
+		if templateOnlyIdents[fn.val] {
+			p.unsupported = true
+		}
+		return nil, nil, newPatternError(p.src, fn.pos, "unknown function %q", fn.val)
+	}
+
+	wantArgs := builtin.args
+	if !piped {
+		wantArgs++
+	}
+	if len(args) != wantArgs {
+		pos := fn.pos
+		if len(args) > wantArgs {
+			pos = args[wantArgs].pos
+		}
+		return nil, nil, newPatternError(p.src, pos, "wrong number of arguments for %v: want %v, got %v", fn.val, wantArgs, len(args))
+	}
+
+	var input patternSegment
+	if !piped {
+		var err error
+		input, err = p.compileOperand(args[len(args)-1], inRange)
+		if err != nil {
+			return nil, nil, err
+		}
+		args = args[:len(args)-1]
+	}
+
+	numbers := make([]int, len(args))
+	for i, arg := range args {
+		n, err := strconv.Atoi(arg.val)
+		if arg.kind != tokenNumber || err != nil {
+			return nil, nil, newPatternError(p.src, arg.pos, "argument of %v must be a number, found %v", fn.val, p.describe(arg))
+		}
+		numbers[i] = n
+	}
+	return input, builtin.filter(numbers), nil
+}
+
+// compileOperand compiles an argument of a function, which is a verb
+// without arguments or a constant, into a segment that writes it.
+func (p *templateParser) compileOperand(arg patternToken, inRange bool) (patternSegment, error) {
+	switch arg.kind {
+	case tokenField:
+		segment, err := p.compileAction([]patternToken{arg}, inRange)
+		if err != nil {
+			return nil, err
+		}
+		return segment, nil
+	case tokenString, tokenNumber:
+		s := arg.val
+		return func(buf *bytes.Buffer, _ *patternContext) {
+			buf.WriteString(s)
+		}, nil
+	}
+	return nil, newPatternError(p.src, arg.pos, "unexpected %v, functions can only be called at the beginning of a command", p.describe(arg))
+}
+
+var (
+	stringType = reflect.TypeOf("")
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// compileFuncCall compiles the call of a user function with the given
+// arguments into a filter.
+// Constants are converted to the parameter types when the pattern is
+// compiled. Verbs and the piped output are passed as strings.
+func (p *templateParser) compileFuncCall(fn patternToken, f interface{}, args []patternToken, inRange, piped bool) (patternFilter, error) {
+	fv := reflect.ValueOf(f)
+	ft := fv.Type()
+	if fv.Kind() != reflect.Func || fv.IsNil() {
+		return nil, newPatternError(p.src, fn.pos, "%q is not a function", fn.val)
+	}
+	if ft.NumOut() != 1 && (ft.NumOut() != 2 || ft.Out(1) != errorType) {
+		return nil, newPatternError(p.src, fn.pos, "function %q must return one value, or one value and an error", fn.val)
+	}
+
+	numArgs := len(args)
+	if piped {
+		numArgs++
+	}
+	if numArgs != ft.NumIn() && (!ft.IsVariadic() || numArgs < ft.NumIn()-1) {
+		pos := fn.pos
+		if len(args) > ft.NumIn() {
+			pos = args[ft.NumIn()].pos
+		}
+		return nil, newPatternError(p.src, pos, "wrong number of arguments for %v: want %v, got %v", fn.val, ft.NumIn(), numArgs)
+	}
+	paramType := func(i int) reflect.Type {
+		if ft.IsVariadic() && i >= ft.NumIn()-1 {
+			return ft.In(ft.NumIn() - 1).Elem()
+		}
+		return ft.In(i)
+	}
+	acceptsString := func(t reflect.Type) bool {
+		return t.Kind() == reflect.String || t.Kind() == reflect.Interface && stringType.Implements(t)
+	}
+
+	// every argument is either a constant value or a segment,
+	// whose output is passed as string
+	values := make([]reflect.Value, len(args))
+	operands := make([]patternSegment, len(args))
+	for i, arg := range args {
+		t := paramType(i)
+		switch arg.kind {
+		case tokenString:
+			if !acceptsString(t) {
+				return nil, newPatternError(p.src, arg.pos, "can't use %v as %v in argument of %v", p.describe(arg), t, fn.val)
+			}
+			values[i] = reflect.ValueOf(arg.val).Convert(t)
+		case tokenNumber:
+			v, ok := numberValue(arg.val, t)
+			if !ok {
+				return nil, newPatternError(p.src, arg.pos, "can't use %v as %v in argument of %v", p.describe(arg), t, fn.val)
+			}
+			values[i] = v
+		default:
+			if !acceptsString(t) {
+				return nil, newPatternError(p.src, arg.pos, "can't use %v as %v in argument of %v", p.describe(arg), t, fn.val)
+			}
+			segment, err := p.compileOperand(arg, inRange)
+			if err != nil {
+				return nil, err
+			}
+			operands[i] = segment
+		}
+	}
+	var pipedType reflect.Type
+	if piped {
+		pipedType = paramType(len(args))
+		if !acceptsString(pipedType) {
+			return nil, newPatternError(p.src, fn.pos, "can't pipe into %v, its last parameter is %v", fn.val, pipedType)
+		}
+	}
+
+	name := fn.val
+	return func(buf *bytes.Buffer, start int, ctx *patternContext) {
+		in := make([]reflect.Value, len(values), numArgs)
+		for i, v := range values {
+			if operands[i] == nil {
+				in[i] = v
+				continue
+			}
+			var operand bytes.Buffer
+			operands[i](&operand, ctx)
+			in[i] = reflect.ValueOf(operand.String()).Convert(paramType(i))
+		}
+		if piped {
+			in = append(in, reflect.ValueOf(string(buf.Bytes()[start:])).Convert(pipedType))
+			buf.Truncate(start)
+		}
+
+		result, err := callFunc(fv, in)
+		if err != nil {
+			ctx.fail(fmt.Errorf("calling %v: %w", name, err))
+			fmt.Fprintf(buf, "%%!%v(%v)", name, err)
+			return
+		}
+		if result.Kind() == reflect.String {
+			buf.WriteString(result.String())
+			return
+		}
+		fmt.Fprint(buf, result.Interface())
+	}, nil
+}
+
+// callFunc calls the given function with the given arguments and
+// returns its result.
+// The returned error is either the error that the function returned,
+// or an error that describes a panic of the function.
+func callFunc(fv reflect.Value, in []reflect.Value) (result reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	out := fv.Call(in)
+	if len(out) == 2 && !out[1].IsNil() {
+		return reflect.Value{}, out[1].Interface().(error)
+	}
+	return out[0], nil
+}
+
+// numberValue converts the given number constant to the given type.
+// Numbers are passed as int to parameters of an interface type.
+func numberValue(number string, t reflect.Type) (reflect.Value, bool) {
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return reflect.Value{}, false
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v := reflect.New(t).Elem()
+		if v.OverflowInt(n) {
+			return reflect.Value{}, false
+		}
+		v.SetInt(n)
+		return v, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v := reflect.New(t).Elem()
+		if n < 0 || v.OverflowUint(uint64(n)) {
+			return reflect.Value{}, false
+		}
+		v.SetUint(uint64(n))
+		return v, true
+	case reflect.Float32, reflect.Float64:
+		return reflect.ValueOf(float64(n)).Convert(t), true
+	case reflect.Interface:
+		v := reflect.ValueOf(int(n))
+		if !v.Type().Implements(t) {
+			return reflect.Value{}, false
+		}
+		return v.Convert(t), true
+	}
+	return reflect.Value{}, false
+}
+
+// processStart is the time at which this package was initialized,
+// which is usually the start of the process.
+var processStart = time.Now()
+
+var (
+	hostname = sync.OnceValue(func() string {
+		name, _ := os.Hostname()
+		return name
+	})
+	pid = strconv.Itoa(os.Getpid())
+)
+
+func writeHostname(buf *bytes.Buffer, _ *patternContext) {
+	buf.WriteString(hostname())
+}
+
+func writePID(buf *bytes.Buffer, _ *patternContext) {
+	buf.WriteString(pid)
+}
+
+// writeGoroutineID writes the id of the goroutine that formats the
+// record, which is the goroutine of the log call, unless the logger
+// is wrapped in an AsyncLogger.
+func writeGoroutineID(buf *bytes.Buffer, _ *patternContext) {
+	var stack [64]byte
+	// the stack starts with "goroutine 17 [running]:"
+	b := stack[:runtime.Stack(stack[:], false)]
+	b = b[len("goroutine "):]
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	buf.Write(b)
+}
+
+// writeElapsed writes the milliseconds between the start of the process
+// and the time of the record.
+func writeElapsed(buf *bytes.Buffer, ctx *patternContext) {
+	buf.Grow(20)
+	buf.Write(strconv.AppendInt(buf.AvailableBuffer(), ctx.rec.Time.Sub(processStart).Milliseconds(), 10))
+}
+
+// writeSeq writes the sequence number of the record, which is taken
+// from the counter of the logger once per record.
+func writeSeq(buf *bytes.Buffer, ctx *patternContext) {
+	if !ctx.seqResolved && ctx.counter != nil {
+		ctx.seq = atomic.AddUint64(ctx.counter, 1)
+		ctx.seqResolved = true
+	}
+	buf.Grow(20)
+	buf.Write(strconv.AppendUint(buf.AvailableBuffer(), ctx.seq, 10))
+}
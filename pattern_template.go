@@ -0,0 +1,138 @@
+package abc
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// templateOnlyIdents are the keywords, builtin functions and constants
+// of go templates, that patterns can use, but that are not compiled
+// into segments. Patterns that use them are executed with text/template.
+var templateOnlyIdents = map[string]bool{
+	"if": true, "else": true, "with": true, "define": true, "template": true,
+	"block": true, "break": true, "continue": true,
+	"and": true, "or": true, "not": true, "len": true, "index": true,
+	"slice": true, "print": true, "printf": true, "println": true,
+	"html": true, "js": true, "urlquery": true, "call": true,
+	"eq": true, "ne": true, "lt": true, "le": true, "gt": true, "ge": true,
+	"true": true, "false": true, "nil": true,
+}
+
+// templateErrorPrefix is the prefix of the errors of text/template,
+// which is replaced by the position of the error in the pattern.
+var templateErrorPrefix = regexp.MustCompile(`^template: pattern:\d+(:\d+)?: `)
+
+// compileTextTemplate compiles the pattern of this parser, which uses
+// constructs of go templates that are not compiled into segments,
+// into a single segment, that executes the pattern with text/template.
+// The verbs are methods of the template data and the builtin
+// functions are available with the same arguments as in segments.
+// If the pattern is invalid for text/template as well, an error at
+// the position of the given error is returned.
+func (p *templateParser) compileTextTemplate(cause *PatternError) (compiled *compiledPattern, err error) {
+	defer func() {
+		// Funcs panics, if a function can't be called in a template
+		if r := recover(); r != nil {
+			compiled, err = nil, newPatternError(p.src, cause.Offset, "%v", r)
+		}
+	}()
+
+	tmpl := template.New("pattern").Funcs((&templateData{}).builtins(nil)).Funcs(p.funcs)
+	if _, err := tmpl.Parse(p.src); err != nil {
+		return nil, newPatternError(p.src, cause.Offset, "%v", templateErrorPrefix.ReplaceAllString(err.Error(), ""))
+	}
+
+	// every execution needs its own clone of the template, whose
+	// color function knows the colors of the record
+	states := &sync.Pool{
+		New: func() interface{} {
+			clone, _ := tmpl.Clone()
+			data := &templateData{tmpl: clone}
+			clone.Funcs(data.builtins(p.funcs))
+			return data
+		},
+	}
+	segment := func(buf *bytes.Buffer, ctx *patternContext) {
+		data := states.Get().(*templateData)
+		data.ctx = ctx
+		if err := data.tmpl.Execute(buf, data); err != nil {
+			ctx.fail(err)
+			fmt.Fprintf(buf, "%%!template(%v)", err)
+		}
+		data.ctx = nil
+		states.Put(data)
+	}
+
+	return &compiledPattern{
+		source:   p.src,
+		segments: []patternSegment{segment},
+		// the caller is recorded, if the pattern may print it
+		needsCaller: strings.Contains(p.src, ".File") || strings.Contains(p.src, ".Line") || strings.Contains(p.src, ".Function"),
+	}, nil
+}
+
+// templateData is the data of a pattern, that is executed with
+// text/template. Its methods are the verbs of the pattern.
+type templateData struct {
+	tmpl *template.Template
+	ctx  *patternContext
+}
+
+// builtins returns the builtin functions, except for the ones
+// that are overridden by the given functions.
+func (d *templateData) builtins(override template.FuncMap) template.FuncMap {
+	funcs := template.FuncMap{}
+	for name, builtin := range patternBuiltins {
+		if _, ok := override[name]; ok {
+			continue
+		}
+		builtin := builtin
+		if builtin.args == 0 {
+			funcs[name] = func(v interface{}) string {
+				return d.filter(builtin.filter(nil), v)
+			}
+			continue
+		}
+		funcs[name] = func(n int, v interface{}) string {
+			return d.filter(builtin.filter([]int{n}), v)
+		}
+	}
+	return funcs
+}
+
+// filter returns the given value, transformed by the given filter.
+func (d *templateData) filter(filter patternFilter, v interface{}) string {
+	var buf bytes.Buffer
+	fmt.Fprint(&buf, v)
+	filter(&buf, 0, d.ctx)
+	return buf.String()
+}
+
+// write returns the output of the given segment.
+func (d *templateData) write(segment patternSegment) string {
+	var buf bytes.Buffer
+	segment(&buf, d.ctx)
+	return buf.String()
+}
+
+func (d *templateData) Level() string                   { return d.write(writeLevel) }
+func (d *templateData) Message() string                 { return d.ctx.rec.Message }
+func (d *templateData) Fields() Fields                  { return d.ctx.rec.Fields }
+func (d *templateData) Timestamp() string               { return d.Timestampf(TimeLayoutCustomPatternLogger) }
+func (d *templateData) Timestampf(layout string) string { return d.ctx.rec.Time.Format(layout) }
+func (d *templateData) File() string                    { return d.Filef("short") }
+func (d *templateData) Filef(mode string) string        { return d.write(fileSegment(mode)) }
+func (d *templateData) Line() string                    { return d.write(writeLine) }
+func (d *templateData) Function() string                { return d.Functionf("package") }
+func (d *templateData) Functionf(mode string) string    { return d.write(functionSegment(mode)) }
+func (d *templateData) Name() string                    { return d.write(writeName) }
+func (d *templateData) Field(key string) string         { return d.write(fieldSegment(key)) }
+func (d *templateData) Hostname() string                { return hostname() }
+func (d *templateData) PID() string                     { return pid }
+func (d *templateData) GoroutineID() string             { return d.write(writeGoroutineID) }
+func (d *templateData) Seq() string                     { return d.write(writeSeq) }
+func (d *templateData) Elapsed() string                 { return d.write(writeElapsed) }
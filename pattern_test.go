@@ -0,0 +1,298 @@
+package abc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileTemplatePattern(t *testing.T) {
+	rec := &Record{
+		Time:    time.Date(2018, 11, 24, 15, 26, 44, 453000000, time.UTC),
+		Level:   LevelWarn,
+		Name:    "app.db",
+		Message: "Hello World!",
+		Fields:  Fields{{"request", 17}, {"user", "John Doe"}},
+	}
+
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"plain text", "plain text"},
+		{"{{.Timestamp}} [{{.Level}}] - {{.Message}}\n", "2018-11-24 15:26:44.453 [WARN] - Hello World!\n"},
+		{`{{.Timestampf "15:04"}} {{ .Message }}`, "15:26 Hello World!"},
+		{`{{.Timestampf "}}"}}`, "}}"},
+		{"{{.Fields}}|{{range .Fields}}{{.Key}}={{.Value}};{{end}}", `request=17 user="John Doe"|request=17;user=John Doe;`},
+		{"a {{/* comment */}}b", "a b"},
+		{"a   {{- .Level -}}  \n b", "aWARNb"},
+		{"{{.Line}} {{.File}} {{.Function}}", "0 . ."},
+		{`{{.Name}}|{{.Field "user"}}|{{.Field "unknown"}}`, "app.db|John Doe|"},
+		{"{{.Level | pad 6}}|{{.Level | pad -6}}|{{pad -6 .Level}}|{{.Level | pad 2}}", "  WARN|WARN  |WARN  |WARN"},
+		{"{{.Message | trunc 5}}|{{.Message | trunc -6}}|{{trunc 3 `äöüß`}}", "Hello|World!|äöü"},
+		{"{{.Message | upper}}|{{lower .Message}}|{{upper `äöü`}}", "HELLO WORLD!|hello world!|ÄÖÜ"},
+		{"{{.Message | trunc 5 | upper | pad -7}}|", "HELLO  |"},
+		{"{{range .Fields}}{{.Key | upper}}={{.Value | trunc 4}};{{end}}", "REQUEST=17;USER=John;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			assert := assert.New(t)
+
+			p, err := compileTemplatePattern(tt.pattern, nil)
+			if assert.NoError(err) {
+				buf := &bytes.Buffer{}
+				p.execute(buf, rec)
+				assert.Equal(tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestCompileTemplatePattern_Process(t *testing.T) {
+	assert := assert.New(t)
+
+	p, err := compileTemplatePattern("{{.Hostname}} {{.PID}} {{.Elapsed}} {{.Seq}}/{{.Seq}} {{.GoroutineID}}", nil)
+	if !assert.NoError(err) {
+		return
+	}
+
+	rec := &Record{Time: processStart.Add(1500 * time.Millisecond)}
+	buf := &bytes.Buffer{}
+	counter := new(uint64)
+	p.executeColored(buf, rec, nil, counter)
+	host, _ := os.Hostname()
+	assert.Regexp(fmt.Sprintf(`^%v %v 1500 1/1 \d+$`, regexp.QuoteMeta(host), os.Getpid()), buf.String())
+
+	buf.Reset()
+	p.executeColored(buf, rec, nil, counter)
+	assert.Contains(buf.String(), " 2/2 ", "Seq must be increased once per record")
+}
+
+func TestCompileTemplatePattern_Funcs(t *testing.T) {
+	rec := &Record{
+		Level:   LevelInfo,
+		Message: "Hello World!",
+	}
+	funcs := template.FuncMap{
+		"repeat": strings.Repeat,
+		"printf": fmt.Sprintf,
+		"const":  func() string { return "constant" },
+		"len":    func(s string) int { return len(s) },
+		"fail":   func(string) (string, error) { return "", errors.New("failed") },
+		"upper":  func(s string) string { return "custom " + s },
+	}
+
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"{{const}}", "constant"},
+		{"{{.Message | len}}", "12"},
+		{"{{repeat .Level 2}}", "INFOINFO"},
+		{`{{repeat "ab" 3 | pad 8}}`, "  ababab"},
+		{`{{.Level | printf "%v-%v" 17}}`, "17-INFO"},
+		{`{{printf "%v"}}`, "%!v(MISSING)"},
+		{"{{.Message | fail}}", "%!fail(failed)"},
+		{"{{.Level | upper}}", "custom INFO"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			assert := assert.New(t)
+
+			p, err := compileTemplatePattern(tt.pattern, funcs)
+			if assert.NoError(err) {
+				buf := &bytes.Buffer{}
+				p.execute(buf, rec)
+				assert.Equal(tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestCompileTemplatePattern_TextTemplate(t *testing.T) {
+	rec := &Record{
+		Level:   LevelWarn,
+		Name:    "app.db",
+		Message: "Hello World!",
+		Fields:  Fields{{"request", 17}, {"user", "John Doe"}},
+	}
+	funcs := template.FuncMap{
+		"upper": func(s string) string { return "custom " + s },
+	}
+
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`{{if eq .Level "WARN"}}!{{end}}{{.Message}}`, "!Hello World!"},
+		{`{{printf "%-6s|" .Level}}{{.Message | len}}`, "WARN  |12"},
+		{"{{range $i, $f := .Fields}}{{$i}}:{{$f.Key}} {{end}}", "0:request 1:user "},
+		{"{{with .Name}}<{{.}}>{{end}}{{with .Field `unknown`}}{{.}}{{else}}-{{end}}", "<app.db>-"},
+		{"{{if .Fields}}{{.Fields | pad 30 | trunc 10}}{{end}}|{{.Level | lower}}", "    reques|warn"},
+		{"{{$l := .Level}}{{upper $l}} {{.Timestampf `2006`}} {{.Line}}", "custom WARN 0001 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			assert := assert.New(t)
+
+			p, err := compileTemplatePattern(tt.pattern, funcs)
+			if assert.NoError(err) {
+				buf := &bytes.Buffer{}
+				assert.NoError(p.execute(buf, rec))
+				assert.Equal(tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestCompileTemplatePattern_TextTemplateColor(t *testing.T) {
+	assert := assert.New(t)
+
+	p, err := compileTemplatePattern("{{if true}}{{color .Level}}{{end}} {{.Message}}", nil)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.False(p.needsCaller)
+
+	rec := &Record{Level: LevelError, Message: "abc"}
+	buf := &bytes.Buffer{}
+	p.executeColored(buf, rec, DefaultColorTheme().codes(), nil)
+	assert.Equal(string(ColorRed)+"ERR "+string(ColorReset)+" abc", buf.String())
+
+	buf.Reset()
+	p.execute(buf, rec)
+	assert.Equal("ERR  abc", buf.String(), "No color codes must be written without colors")
+}
+
+func TestCompileTemplatePattern_TextTemplateErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := compileTemplatePattern("abc {{if .Level}}", nil)
+	assert.EqualError(err, "pattern:1:7: unexpected EOF")
+	_, ok := err.(*PatternError)
+	assert.True(ok, "Errors must be of type *PatternError")
+
+	_, err = compileTemplatePattern("{{if .Level}}{{foo}}{{end}}", nil)
+	assert.EqualError(err, `pattern:1:3: function "foo" not defined`)
+
+	p, err := compileTemplatePattern("{{if .Foo}}x{{end}}{{.Message}}", nil)
+	if assert.NoError(err) {
+		buf := &bytes.Buffer{}
+		assert.Error(p.execute(buf, &Record{Message: "abc"}), "Execution errors must be reported")
+		assert.Contains(buf.String(), "%!template(")
+	}
+
+	p, err = compileTemplatePattern("{{if true}}{{.Line}}{{end}}", nil)
+	if assert.NoError(err) {
+		assert.True(p.needsCaller)
+	}
+}
+
+func TestCompileTemplatePattern_FuncErrors(t *testing.T) {
+	funcs := template.FuncMap{
+		"repeat": strings.Repeat,
+		"nofunc": "abc",
+		"values": func() (string, string) { return "", "" },
+		"number": func(int) string { return "" },
+	}
+
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"{{nofunc}}", `pattern:1:3: "nofunc" is not a function`},
+		{"{{values}}", `pattern:1:3: function "values" must return one value, or one value and an error`},
+		{"{{repeat 1}}", "pattern:1:3: wrong number of arguments for repeat: want 2, got 1"},
+		{`{{repeat "a" 1 2}}`, "pattern:1:16: wrong number of arguments for repeat: want 2, got 3"},
+		{`{{repeat "a" "b"}}`, `pattern:1:14: can't use "b" as int in argument of repeat`},
+		{`{{.Message | repeat "a"}}`, "pattern:1:14: can't pipe into repeat, its last parameter is int"},
+		{"{{.Message | repeat}}", "pattern:1:14: wrong number of arguments for repeat: want 2, got 1"},
+		{"{{number .Line}}", "pattern:1:10: can't use .Line as int in argument of number"},
+		{"{{.Message | number}}", "pattern:1:14: can't pipe into number, its last parameter is int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			assert := assert.New(t)
+
+			_, err := compileTemplatePattern(tt.pattern, funcs)
+			assert.EqualError(err, tt.want)
+		})
+	}
+}
+
+func TestCompileTemplatePattern_Errors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"{{.Foo}}", "pattern:1:3: unknown verb .Foo"},
+		{"[{{.Level}}]\n  {{.Message", "pattern:2:3: action is not closed with }}"},
+		{"{{.Timestampf}}", "pattern:1:3: wrong number of arguments for .Timestampf: want 1, got 0"},
+		{`{{.Level "x"}}`, "pattern:1:10: wrong number of arguments for .Level: want 0, got 1"},
+		{"{{.Filef 1}}", "pattern:1:10: argument of .Filef must be a string, found 1"},
+		{"{{.Message | foo}}", `pattern:1:14: unknown function "foo"`},
+		{"{{foo .Message}}", `pattern:1:3: unknown function "foo"`},
+		{"{{.Message | .Level}}", "pattern:1:14: unexpected .Level, expected a function after '|'"},
+		{"{{.Message |}}", "pattern:1:12: missing command in pipeline"},
+		{"{{| upper}}", "pattern:1:3: missing command in pipeline"},
+		{"{{.Message | pad}}", "pattern:1:14: wrong number of arguments for pad: want 1, got 0"},
+		{"{{.Message | pad 1 2}}", "pattern:1:20: wrong number of arguments for pad: want 1, got 2"},
+		{`{{.Message | trunc "x"}}`, `pattern:1:20: argument of trunc must be a number, found "x"`},
+		{"{{upper}}", "pattern:1:3: wrong number of arguments for upper: want 1, got 0"},
+		{"{{upper .Timestampf}}", "pattern:1:9: wrong number of arguments for .Timestampf: want 1, got 0"},
+		{"{{upper lower}}", "pattern:1:9: unexpected lower, functions can only be called at the beginning of a command"},
+		{"{{}}", "pattern:1:1: empty action"},
+		{`{{.Timestampf "abc}}`, "pattern:1:15: string is not terminated"},
+		{"{{range .Fields}}{{.Level}}{{end}}", "pattern:1:20: unknown verb .Level, only .Key and .Value are available in {{range .Fields}}"},
+		{"{{range .Fields}}{{.Key}}", "pattern:1:1: {{range}} is not closed with {{end}}"},
+		{"{{range .Fields}}{{range .Fields}}{{end}}{{end}}", "pattern:1:20: nested {{range}} is not supported"},
+		{"{{end}}", "pattern:1:3: unexpected {{end}}"},
+		{"{{.Key}}", "pattern:1:3: unknown verb .Key"},
+		{"{{/* comment }}", "pattern:1:3: comment is not closed"},
+		{"äöü {{.Level x}}", `pattern:1:14: argument of .Level must be a string, found x`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			assert := assert.New(t)
+
+			_, err := compileTemplatePattern(tt.pattern, nil)
+			assert.EqualError(err, tt.want)
+			_, ok := err.(*PatternError)
+			assert.True(ok, "Errors must be of type *PatternError")
+		})
+	}
+}
+
+func TestNewCustomPatternLogger_InvalidPattern(t *testing.T) {
+	assert := assert.New(t)
+
+	logger, err := NewCustomPatternLogger("{{.Foo}}")
+	assert.EqualError(err, "pattern:1:3: unknown verb .Foo")
+	assert.Equal(CustomPatternLoggerDefaultPattern, logger.(*CustomPatternLogger).Pattern(), "The default pattern must be used")
+}
+
+func TestCompileTemplatePattern_Color(t *testing.T) {
+	assert := assert.New(t)
+
+	p, err := compileTemplatePattern("{{color .Level}} - {{.Message | upper | color}}", nil)
+	if !assert.NoError(err) {
+		return
+	}
+
+	rec := &Record{Level: LevelWarn, Message: "abc"}
+	buf := &bytes.Buffer{}
+	p.executeColored(buf, rec, DefaultColorTheme().codes(), nil)
+	assert.Equal(string(ColorYellow)+"WARN"+string(ColorReset)+" - "+string(ColorYellow)+"ABC"+string(ColorReset), buf.String())
+
+	buf.Reset()
+	p.execute(buf, rec)
+	assert.Equal("WARN - ABC", buf.String(), "No color codes must be written without colors")
+}
@@ -0,0 +1,128 @@
+package abc
+
+import (
+	"bytes"
+	"runtime"
+	"time"
+)
+
+// Record is a single log message, together with all
+// information that a formatter or handler might need
+// to print it.
+// Records are created by the loggers of this package for every
+// message that passes the level check.
+type Record struct {
+	// Time is the time at which the message was logged.
+	Time time.Time
+	// Level is the level of the message.
+	Level LogLevel
+	// Message is the already formatted message.
+	Message string
+	// PC is the program counter of the log call, or 0 if the
+	// logger does not record callers.
+	PC uintptr
+	// Name is the name of the logger, or an empty string
+	// if the logger has no name.
+	Name string
+	// Fields are the fields of the logger.
+	// Handlers must not modify them.
+	Fields Fields
+}
+
+// Caller returns the file, line and function of the log call.
+// If the PC of the record is 0, the zero Frame is returned.
+func (r *Record) Caller() runtime.Frame {
+	if r.PC == 0 {
+		return runtime.Frame{}
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+	return frame
+}
+
+// RecordLogger is implemented by loggers that can log records,
+// which were built outside of the logger, for example by a
+// slog.Logger.
+// All loggers of this package implement RecordLogger.
+type RecordLogger interface {
+	Logger
+
+	// Log logs the given record, if and only if its level is
+	// enabled by this logger.
+	// The logger adds its name and its fields to the record,
+	// and uses its clock if the time of the record is zero.
+	Log(*Record) error
+}
+
+// logRecord logs the given record with the given logger.
+// If the logger is not a RecordLogger, the record's fields are
+// attached with With and the message is printed with Print,
+// which loses the time and the caller of the record.
+// If the logger is no FieldLogger either, the fields are
+// appended to the message.
+func logRecord(logger Logger, rec *Record) error {
+	if rl, ok := logger.(RecordLogger); ok {
+		return rl.Log(rec)
+	}
+
+	msg := rec.Message
+	if len(rec.Fields) > 0 {
+		if fl, ok := logger.(FieldLogger); ok {
+			keyvals := make([]interface{}, 0, 2*len(rec.Fields))
+			for _, field := range rec.Fields {
+				keyvals = append(keyvals, field.Key, field.Value)
+			}
+			logger = fl.With(keyvals...)
+		} else {
+			msg += " " + rec.Fields.String()
+		}
+	}
+	logger.Print(rec.Level, msg)
+	return nil
+}
+
+// Handler handles records that were created by a logger,
+// for example by formatting and writing them somewhere.
+// Handlers must be safe for concurrent use.
+type Handler interface {
+	// Handle handles the given record.
+	// The record must not be retained after Handle returned.
+	Handle(*Record) error
+}
+
+// LevelHandler is a Handler that has its own notion of enabled
+// levels, for example because it forwards records to a system
+// with its own level configuration.
+// Loggers only handle a message, if both their own level and
+// their LevelHandler enable it.
+type LevelHandler interface {
+	Handler
+
+	// Enabled returns true if and only if the handler
+	// handles records of the given level.
+	Enabled(LogLevel) bool
+}
+
+// HandlerFunc is an adapter to use ordinary functions as Handler.
+type HandlerFunc func(*Record) error
+
+// Handle calls f(rec).
+func (f HandlerFunc) Handle(rec *Record) error {
+	return f(rec)
+}
+
+// Formatter formats records into their textual representation.
+// Formatters must be safe for concurrent use.
+type Formatter interface {
+	// Format writes the formatted record to the given buffer.
+	// The output should end with a line break.
+	Format(*bytes.Buffer, *Record) error
+}
+
+// FormatterFunc is an adapter to use ordinary functions as Formatter.
+type FormatterFunc func(*bytes.Buffer, *Record) error
+
+// Format calls f(buf, rec).
+func (f FormatterFunc) Format(buf *bytes.Buffer, rec *Record) error {
+	return f(buf, rec)
+}
@@ -0,0 +1,24 @@
+package abc
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord_Caller(t *testing.T) {
+	assert := assert.New(t)
+
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:]) // this is line 15
+
+	rec := &Record{PC: pcs[0]}
+	frame := rec.Caller()
+	assert.Equal("record_test.go", filepath.Base(frame.File))
+	assert.Equal(15, frame.Line)
+	assert.Equal("github.com/TimSatke/abc.TestRecord_Caller", frame.Function)
+
+	assert.Equal(runtime.Frame{}, (&Record{}).Caller(), "Caller of a record without PC must be empty")
+}
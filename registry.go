@@ -0,0 +1,224 @@
+package abc
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// RootLoggerName is the name of the root of the registry, GetLogger(""),
+// with which it is listed by Loggers, matched by the patterns of
+// SetLevels and addressed by the handler of NewLevelAdminHandler.
+// The name of the root itself is empty, so that it is not part of the
+// names of its children.
+const RootLoggerName = "root"
+
+var (
+	// registryRoot is the unnamed root of the hierarchy of all
+	// loggers returned by GetLogger.
+	registryRoot = newRegistryRoot()
+	// levelRules are the rules of the last call of SetLevels.
+	// They are guarded by hierarchyMux.
+	levelRules []levelRule
+)
+
+func newRegistryRoot() *NamedLogger {
+	root := NewNamedLogger("").(*NamedLogger)
+	root.node = &loggerNode{logger: root, registered: true}
+	return root
+}
+
+// levelRule sets the level of all registered loggers, whose name
+// matches the pattern.
+type levelRule struct {
+	pattern string
+	// exact is whether the pattern contains no wildcards.
+	exact bool
+	lvl   LogLevel
+}
+
+// GetLogger returns the NamedLogger with the given dotted name from
+// the global registry of loggers, which is created if it doesn't exist
+// yet, together with all its parents, e.g. GetLogger("app.db") creates
+// the loggers app and app.db.
+// Every call with the same name returns the same logger, so loggers
+// can be found anywhere in the program.
+//
+// The loggers of the registry form a hierarchy as described at
+// NamedLogger.Child, whose root is GetLogger(""), which is also
+// returned for the RootLoggerName. Children, that are created with
+// Child, are part of the registry as well.
+// The root prints to os.Stdout with the level INFO by default.
+// The level rules of SetLevels apply to all loggers of the registry,
+// including the ones that are created later.
+//
+// Loggers, that are created with NewNamedLogger, and their children
+// are not part of the registry, and neither is the logger of Root,
+// unless the root of the registry is made the root logger with
+//
+//	abc.SetRoot(abc.GetLogger(""))
+func GetLogger(name string) *NamedLogger {
+	if name == RootLoggerName {
+		return registryRoot
+	}
+	return registryRoot.Child(name)
+}
+
+// registryName returns the name of the given logger of the
+// registry, which is the RootLoggerName for the root.
+func registryName(logger *NamedLogger) string {
+	if logger == registryRoot {
+		return RootLoggerName
+	}
+	return logger.Name()
+}
+
+// lookupLogger returns the logger of the registry with the given
+// name, without creating it.
+func lookupLogger(name string) (*NamedLogger, bool) {
+	if name == RootLoggerName {
+		return registryRoot, true
+	}
+
+	hierarchyMux.Lock()
+	defer hierarchyMux.Unlock()
+
+	node := registryRoot.node
+	for _, part := range strings.Split(name, ".") {
+		if part == "" {
+			continue
+		}
+		child, ok := node.children[part]
+		if !ok {
+			return nil, false
+		}
+		node = child
+	}
+	return node.logger, true
+}
+
+// Loggers returns all loggers of the registry, starting with the
+// root, whose name is empty, followed by all other loggers sorted by
+// their name.
+func Loggers() []*NamedLogger {
+	hierarchyMux.Lock()
+	defer hierarchyMux.Unlock()
+
+	var loggers []*NamedLogger
+	registryRoot.node.walk(func(n *loggerNode) {
+		loggers = append(loggers, n.logger)
+	})
+	sort.Slice(loggers[1:], func(i, j int) bool {
+		return loggers[i+1].Name() < loggers[j+1].Name()
+	})
+	return loggers
+}
+
+// SetLevels sets the levels of the loggers of the registry by the
+// names of the loggers. The given rules are separated by commas,
+// and consist of a pattern and a level, e.g.
+//
+//	abc.SetLevels("app.db.*=debug,app.http=warn,*=info")
+//
+// The patterns are matched against the whole name of a logger with
+// path.Match, where * also matches dots, so that app.db.* matches
+// all loggers below app.db, but not app.db itself. The root of the
+// registry is matched by its RootLoggerName. The levels are
+// parsed with ParseLogLevel.
+// If several patterns match a name, the pattern without wildcards
+// wins, and otherwise the longest pattern, and the later one of
+// patterns with the same length.
+//
+// The rules replace the rules of the previous call, and apply to all
+// existing loggers of the registry and to all loggers that are created
+// later, until they are replaced. A level, that was set by a rule, is
+// kept until it is set otherwise, or until no rule matches anymore,
+// when the logger gets its previous level again.
+// Loggers, that no rule matches, inherit their level as usual.
+//
+// If the rules are invalid, an error is returned and no level is changed.
+func SetLevels(rules string) error {
+	parsed, err := parseLevelRules(rules)
+	if err != nil {
+		return err
+	}
+
+	hierarchyMux.Lock()
+	defer hierarchyMux.Unlock()
+
+	levelRules = parsed
+	registryRoot.node.applyLevelRules()
+	return nil
+}
+
+// parseLevelRules parses the rules of SetLevels.
+func parseLevelRules(rules string) ([]levelRule, error) {
+	var parsed []levelRule
+	for _, rule := range strings.Split(rules, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		i := strings.LastIndexByte(rule, '=')
+		if i < 0 {
+			return nil, fmt.Errorf("invalid level rule %q, expected pattern=level", rule)
+		}
+		pattern := strings.TrimSpace(rule[:i])
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern in level rule %q: %w", rule, err)
+		}
+		lvl, err := ParseLogLevel(strings.TrimSpace(rule[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid level rule %q: %w", rule, err)
+		}
+
+		parsed = append(parsed, levelRule{
+			pattern: pattern,
+			exact:   !strings.ContainsAny(pattern, `*?[\`),
+			lvl:     lvl,
+		})
+	}
+	return parsed, nil
+}
+
+// matchLevelRule returns the rule, that sets the level of the
+// logger with the given name.
+func matchLevelRule(rules []levelRule, name string) (levelRule, bool) {
+	var best levelRule
+	found := false
+	for _, rule := range rules {
+		if ok, _ := path.Match(rule.pattern, name); !ok {
+			continue
+		}
+		if !found || rule.exact && !best.exact ||
+			rule.exact == best.exact && len(rule.pattern) >= len(best.pattern) {
+			best, found = rule, true
+		}
+	}
+	return best, found
+}
+
+// applyLevelRules applies the level rules to the node and all
+// nodes below it.
+// hierarchyMux must be held.
+func (n *loggerNode) applyLevelRules() {
+	if rule, ok := matchLevelRule(levelRules, registryName(n.logger)); ok {
+		if !n.levelRuled {
+			n.unruledSet, n.unruledLvl = n.levelSet, n.logger.Level()
+		}
+		n.levelSet, n.levelRuled = true, true
+		n.logger.core.SetLevel(rule.lvl)
+	} else if n.levelRuled {
+		n.levelSet, n.levelRuled = n.unruledSet, false
+		n.logger.core.SetLevel(n.unruledLvl)
+	}
+	if !n.levelSet && n.parent != nil {
+		n.logger.core.SetLevel(n.parent.logger.Level())
+	}
+
+	for _, child := range n.children {
+		child.applyLevelRules()
+	}
+}
@@ -0,0 +1,119 @@
+package abc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// registeredNames returns the names of all loggers of the registry,
+// whose name starts with the given prefix.
+func registeredNames(prefix string) []string {
+	var names []string
+	for _, logger := range Loggers() {
+		if strings.HasPrefix(logger.Name(), prefix) {
+			names = append(names, logger.Name())
+		}
+	}
+	return names
+}
+
+func TestGetLogger(t *testing.T) {
+	assert := assert.New(t)
+
+	pool := GetLogger("getlogger.db.pool")
+	assert.Equal("getlogger.db.pool", pool.Name())
+	assert.True(pool == GetLogger("getlogger.db.pool"), "GetLogger must return the same logger for the same name")
+	assert.True(pool.Parent() == GetLogger("getlogger.db"))
+	assert.True(GetLogger("getlogger").Parent() == GetLogger(""), "Top level loggers must be children of the root")
+
+	GetLogger("getlogger.http").Child("server")
+	assert.Equal([]string{"getlogger", "getlogger.db", "getlogger.db.pool", "getlogger.http", "getlogger.http.server"}, registeredNames("getlogger"))
+}
+
+func TestGetLogger_Root(t *testing.T) {
+	assert := assert.New(t)
+	defer SetLevels("")
+
+	root := GetLogger("")
+	assert.True(root == GetLogger(RootLoggerName), "The root must be returned for its name")
+	assert.Equal("", root.Name(), "The root must stay unnamed")
+	assert.Equal("getlogger", GetLogger("getlogger").Name())
+
+	logger, ok := lookupLogger(RootLoggerName)
+	assert.True(ok)
+	assert.True(root == logger, "The root must be found by its name")
+	assert.True(root == Loggers()[0], "The root must be listed first")
+
+	GetLogger("rootrules").SetLevel(LevelInfo)
+	assert.NoError(SetLevels("root=debug"))
+	assert.Equal(LevelDebug, root.Level(), "The root must be matched by its name")
+	assert.Equal(LevelInfo, GetLogger("rootrules").Level())
+}
+
+func TestSetLevels(t *testing.T) {
+	assert := assert.New(t)
+	defer SetLevels("")
+
+	GetLogger("").SetLevel(LevelInfo)
+	app := GetLogger("setlevels")
+	db := GetLogger("setlevels.db")
+	pool := GetLogger("setlevels.db.pool")
+	http := GetLogger("setlevels.http")
+	other := GetLogger("setlevels.other")
+	other.SetLevel(LevelFatal)
+
+	assert.NoError(SetLevels("setlevels.db.*=debug, setlevels.http=warn, setlevels*=error, setlevels.*=info"))
+	assert.Equal(LevelError, app.Level())
+	assert.Equal(LevelInfo, db.Level(), "A pattern with * must not match the logger itself")
+	assert.Equal(LevelDebug, pool.Level(), "The longest pattern must win")
+	assert.Equal(LevelWarn, http.Level(), "Exact patterns must win")
+	assert.Equal(LevelInfo, other.Level(), "Rules must override levels that were set")
+
+	future := GetLogger("setlevels.db.future")
+	assert.Equal(LevelDebug, future.Level(), "Rules must apply to loggers that are created later")
+	child := future.Child("child")
+	assert.Equal(LevelDebug, child.Level(), "Rules must apply to children")
+
+	db.SetLevel(LevelFatal)
+	assert.Equal(LevelFatal, db.Level())
+
+	assert.NoError(SetLevels("setlevels=verbose"))
+	assert.Equal(LevelVerbose, app.Level())
+	assert.Equal(LevelFatal, db.Level(), "Levels that were set after the rules must be kept")
+	assert.Equal(LevelFatal, pool.Level(), "Loggers without rule must inherit their level again")
+	assert.Equal(LevelVerbose, http.Level())
+	assert.Equal(LevelFatal, other.Level(), "Levels that were set before the rules must be restored")
+
+	assert.NoError(SetLevels(""))
+	assert.Equal(LevelInfo, app.Level())
+	assert.Equal(LevelInfo, http.Level())
+}
+
+func TestSetLevels_Errors(t *testing.T) {
+	defer SetLevels("")
+
+	logger := GetLogger("setlevelserrors")
+	logger.SetLevel(LevelWarn)
+
+	tests := []struct {
+		rules string
+		err   string
+	}{
+		{"setlevelserrors", `invalid level rule "setlevelserrors", expected pattern=level`},
+		{"setlevelserrors=loud", `invalid level rule "setlevelserrors=loud": unknown log level "loud"`},
+		{"*=info,setlevels[=debug", `invalid pattern in level rule "setlevels[=debug": syntax error in pattern`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rules, func(t *testing.T) {
+			assert := assert.New(t)
+
+			err := SetLevels(tt.rules)
+			if assert.Error(err) {
+				assert.Equal(tt.err, err.Error())
+			}
+			assert.Equal(LevelWarn, logger.Level(), "Invalid rules must not change any level")
+		})
+	}
+}
@@ -0,0 +1,648 @@
+package abc
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRotatingFileMaxSize is the maximum size of a rotating
+	// file in bytes, that is used if no maximum size is given.
+	DefaultRotatingFileMaxSize = 100 * 1024 * 1024
+)
+
+// RotationInterval is the interval in which a RotatingFile is
+// rotated, independent of its size.
+type RotationInterval uint8
+
+// Available rotation intervals.
+// Intervals start at the full hour or at midnight in the
+// location of the time of the clock.
+const (
+	RotateNever RotationInterval = iota
+	RotateHourly
+	RotateDaily
+)
+
+// start returns the start of the interval that contains t.
+func (i RotationInterval) start(t time.Time) time.Time {
+	switch i {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+// next returns the start of the interval after the one that contains t.
+func (i RotationInterval) next(t time.Time) time.Time {
+	start := i.start(t)
+	switch i {
+	case RotateHourly:
+		return start.Add(time.Hour)
+	case RotateDaily:
+		return time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, start.Location())
+	}
+	return time.Time{}
+}
+
+// RotatingFileOptions configure a RotatingFile.
+type RotatingFileOptions struct {
+	// MaxSize is the maximum size of the log file in bytes.
+	// If a write would exceed the maximum size, the file is rotated
+	// before the write. If MaxSize is 0 or less,
+	// DefaultRotatingFileMaxSize is used.
+	MaxSize int64
+	// MaxBackups is the number of files that are kept when the file
+	// is rotated because of its size.
+	// Rotated files are named <path>.1 (the newest) to <path>.<MaxBackups>
+	// (the oldest). If MaxBackups is 0 or less, rotated files are deleted,
+	// unless an interval, MaxAge, MaxFiles, MaxTotalSize or Compress is set.
+	// Then all rotated files are kept, until the retention policy
+	// removes them. If only an interval or Compress is set, there is no
+	// retention policy and rotated files are never removed, so set
+	// MaxBackups, MaxAge, MaxFiles or MaxTotalSize to limit them.
+	MaxBackups int
+
+	// Interval is the interval in which the file is rotated,
+	// regardless of its size.
+	// If the interval is not RotateNever, the file name of the path is
+	// a layout as used by time.Time.Format, e.g. "app-2006-01-02.log",
+	// and every interval is written to a file named after its start.
+	// Files of past intervals are kept until the retention policy
+	// removes them.
+	Interval RotationInterval
+
+	// MaxAge is the maximum age of rotated files, determined by their
+	// modification time. Older files are deleted.
+	// If MaxAge is 0, files are not deleted because of their age.
+	MaxAge time.Duration
+	// MaxFiles is the maximum number of rotated files that are kept.
+	// The oldest files are deleted first.
+	// If MaxFiles is 0, files are not deleted because of their number.
+	MaxFiles int
+	// MaxTotalSize is the maximum size of all rotated files in bytes.
+	// The oldest files are deleted first.
+	// If MaxTotalSize is 0, files are not deleted because of their size.
+	MaxTotalSize int64
+
+	// Compress indicates whether rotated files are compressed with gzip.
+	// Compressed files get the additional extension ".gz".
+	Compress bool
+}
+
+// keepsBackups returns true if files that are rotated because of
+// their size are kept, even though MaxBackups is 0 or less.
+// They are only limited by MaxAge, MaxFiles and MaxTotalSize,
+// if any of them is set.
+func (o RotatingFileOptions) keepsBackups() bool {
+	return o.Interval != RotateNever || o.needsMill()
+}
+
+// needsMill returns true if rotated files must be compressed or
+// deleted in the background.
+func (o RotatingFileOptions) needsMill() bool {
+	return o.Compress || o.MaxAge > 0 || o.MaxFiles > 0 || o.MaxTotalSize > 0
+}
+
+// RotatingFile is an io.Writer that writes to a file, which is
+// rotated when it exceeds a maximum size or when a new interval starts.
+// Every write goes completely into one file, so as long as the
+// logger writes one line per write (as all loggers of this package do),
+// lines are never split across two files.
+// A single write that is larger than the maximum size is written
+// to an empty file.
+//
+// Rotated files are compressed and deleted according to the
+// retention policy in a background goroutine, which is started
+// after every rotation and when the file is opened.
+// Writes only wait for the background goroutine, if the file must be
+// rotated because of its size while rotated files are being compressed.
+//
+// If the file cannot be rotated, writes go to the current file and
+// the rotation is tried again with the next write. If no file is open,
+// because the new file could not be opened, the next write tries
+// to open it again.
+//
+// RotatingFiles are safe for concurrent use, so one RotatingFile can be
+// the output of several loggers.
+type RotatingFile struct {
+	mu sync.Mutex
+	// pattern is the path layout, if the file is rotated
+	// by time, or an empty string otherwise.
+	pattern string
+	// path is the path of the current file.
+	// It is only changed while holding both locks.
+	path string
+	opts RotatingFileOptions
+	clk  clock
+
+	// file is nil, if the file could not be opened after a
+	// rotation, or if the rotating file is closed.
+	file         *os.File
+	size         int64
+	nextRotation time.Time
+	closed       bool
+
+	// millMux is held while rotated files are renamed,
+	// compressed or deleted.
+	millMux  sync.Mutex
+	millCh   chan struct{}
+	millDone chan struct{}
+}
+
+// newRotatingFile opens a new rotating file, that uses the given
+// clock to determine rotation intervals and the age of rotated files.
+func newRotatingFile(path string, opts RotatingFileOptions, clk clock) (*RotatingFile, error) {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultRotatingFileMaxSize
+	}
+
+	f := &RotatingFile{
+		path: path,
+		opts: opts,
+		clk:  clk,
+	}
+
+	now := clk.Now()
+	if opts.Interval != RotateNever {
+		f.pattern = path
+		if f.intervalPath(now) == f.intervalPath(opts.Interval.next(now)) {
+			return nil, fmt.Errorf("file name %q does not change between intervals", filepath.Base(path))
+		}
+		f.path = f.intervalPath(now)
+		f.nextRotation = opts.Interval.next(now)
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	if opts.needsMill() {
+		f.millCh = make(chan struct{}, 1)
+		f.millDone = make(chan struct{})
+		go f.mill()
+		f.triggerMill()
+	}
+	return f, nil
+}
+
+// Write writes the given bytes to the file, rotating
+// the file before, if the write would exceed the maximum size
+// or a new interval has started.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.ensureOpen(); err != nil {
+		return 0, err
+	}
+
+	if f.opts.Interval != RotateNever {
+		if now := f.clk.Now(); !now.Before(f.nextRotation) {
+			if err := f.rotateInterval(now); err != nil && f.file == nil {
+				return 0, err
+			}
+		}
+	}
+
+	if f.size > 0 && f.size+int64(len(p)) > f.opts.MaxSize {
+		if err := f.rotate(); err != nil && f.file == nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file, regardless of its size.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.ensureOpen(); err != nil {
+		return err
+	}
+	return f.rotate()
+}
+
+// Sync commits the content of the current file to stable storage.
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.ensureOpen(); err != nil {
+		return err
+	}
+	return f.file.Sync()
+}
+
+// Close closes the current file and waits until the background
+// goroutine has finished compressing and deleting rotated files.
+// Writes after Close fail with os.ErrClosed.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return os.ErrClosed
+	}
+	f.closed = true
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	if f.millCh != nil {
+		close(f.millCh)
+	}
+	f.mu.Unlock()
+
+	if f.millDone != nil {
+		<-f.millDone
+	}
+	return err
+}
+
+// Path returns the path of the current file.
+func (f *RotatingFile) Path() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.path
+}
+
+// intervalPath returns the path of the file for the interval
+// that contains t.
+func (f *RotatingFile) intervalPath(t time.Time) string {
+	return filepath.Join(filepath.Dir(f.pattern), f.opts.Interval.start(t).Format(filepath.Base(f.pattern)))
+}
+
+// ensureOpen returns os.ErrClosed if the rotating file is closed,
+// and opens the file again, if it could not be opened after a rotation.
+// The caller must hold the lock.
+func (f *RotatingFile) ensureOpen() error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	if f.file == nil {
+		return f.open()
+	}
+	return nil
+}
+
+// open opens the file at the path of this rotating file for
+// appending, creating the file and its directory if necessary.
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotateInterval closes the current file and opens the file
+// for the interval that contains now.
+// If the current file cannot be closed, it is opened again, so that
+// the rotation is tried again with the next write. If the new file
+// cannot be opened, no file is open afterwards.
+// The caller must hold the lock.
+func (f *RotatingFile) rotateInterval(now time.Time) error {
+	err := f.file.Close()
+	f.file = nil
+	if err == nil {
+		f.millMux.Lock()
+		f.path = f.intervalPath(now)
+		f.millMux.Unlock()
+		f.nextRotation = f.opts.Interval.next(now)
+		f.triggerMill()
+	}
+
+	if openErr := f.open(); err == nil {
+		err = openErr
+	}
+	return err
+}
+
+// rotate closes the current file, shifts all backups by one,
+// moves the current file to the first backup and opens a new file.
+// If the backups cannot be shifted, the current file is opened again,
+// so that the rotation is tried again with the next write. If the new
+// file cannot be opened, no file is open afterwards.
+// The caller must hold the lock.
+func (f *RotatingFile) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+	if err == nil {
+		err = f.shiftBackups()
+		f.triggerMill()
+	}
+
+	if openErr := f.open(); err == nil {
+		err = openErr
+	}
+	return err
+}
+
+// shiftBackups renames <path>.<n> to <path>.<n+1> and <path>
+// to <path>.1, deleting backups beyond the maximum number of backups.
+// Compressed backups are shifted as well.
+// Without a maximum number of backups, the current file is deleted,
+// or, if rotated files are kept, all backups are shifted.
+func (f *RotatingFile) shiftBackups() error {
+	f.millMux.Lock()
+	defer f.millMux.Unlock()
+
+	maxBackups := f.opts.MaxBackups
+	if maxBackups <= 0 {
+		if !f.opts.keepsBackups() {
+			return removeIfExists(f.path)
+		}
+		last, err := f.lastBackup()
+		if err != nil {
+			return err
+		}
+		maxBackups = last + 1
+	}
+
+	for _, ext := range []string{"", ".gz"} {
+		if err := removeIfExists(f.backupPath(maxBackups) + ext); err != nil {
+			return err
+		}
+		for i := maxBackups - 1; i >= 1; i-- {
+			if err := renameIfExists(f.backupPath(i)+ext, f.backupPath(i+1)+ext); err != nil {
+				return err
+			}
+		}
+	}
+	return renameIfExists(f.path, f.backupPath(1))
+}
+
+// lastBackup returns the highest number of the (compressed)
+// backups of the current file, or 0 if there are no backups.
+// The caller must hold the mill lock.
+func (f *RotatingFile) lastBackup() (int, error) {
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return 0, err
+	}
+
+	prefix := filepath.Base(f.path) + "."
+	last := 0
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".gz")
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if n, err := strconv.Atoi(name[len(prefix):]); err == nil && n > last {
+			last = n
+		}
+	}
+	return last, nil
+}
+
+func (f *RotatingFile) backupPath(n int) string {
+	return fmt.Sprintf("%v.%v", f.path, n)
+}
+
+// triggerMill starts a run of the background goroutine,
+// if it is not already pending.
+// The caller must hold the lock.
+func (f *RotatingFile) triggerMill() {
+	if f.millCh == nil {
+		return
+	}
+
+	select {
+	case f.millCh <- struct{}{}:
+	default:
+	}
+}
+
+// mill compresses and deletes rotated files whenever it is
+// triggered, until the file is closed.
+func (f *RotatingFile) mill() {
+	defer close(f.millDone)
+
+	for range f.millCh {
+		f.millMux.Lock()
+		_ = f.millRun()
+		f.millMux.Unlock()
+	}
+}
+
+// rotatedFile is a file that was created by rotation.
+type rotatedFile struct {
+	path    string
+	modTime time.Time
+	size    int64
+	// start is the start of the interval of the file,
+	// if the file is rotated by time.
+	start time.Time
+	// n is the number of the backup, or 0.
+	n int
+}
+
+// newerThan returns true if r was rotated after o.
+// Files with the same modification time are ordered by
+// their interval and their backup number.
+func (r rotatedFile) newerThan(o rotatedFile) bool {
+	if !r.modTime.Equal(o.modTime) {
+		return r.modTime.After(o.modTime)
+	}
+	if !r.start.Equal(o.start) {
+		return r.start.After(o.start)
+	}
+	return r.n < o.n
+}
+
+// millRun compresses all uncompressed rotated files, if compression
+// is enabled, and then deletes rotated files according to the
+// retention policy.
+// The caller must hold the mill lock.
+func (f *RotatingFile) millRun() error {
+	files, err := f.rotatedFiles()
+	if err != nil {
+		return err
+	}
+
+	if f.opts.Compress {
+		for i, file := range files {
+			if strings.HasSuffix(file.path, ".gz") {
+				continue
+			}
+			if err := compressFile(file.path); err != nil {
+				return err
+			}
+			files[i].path += ".gz"
+			if info, err := os.Stat(files[i].path); err == nil {
+				files[i].size = info.Size()
+			}
+		}
+	}
+
+	// newest files first
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].newerThan(files[j])
+	})
+
+	now := f.clk.Now()
+	kept := 0
+	var total int64
+	for _, file := range files {
+		remove := (f.opts.MaxAge > 0 && now.Sub(file.modTime) > f.opts.MaxAge) ||
+			(f.opts.MaxFiles > 0 && kept >= f.opts.MaxFiles) ||
+			(f.opts.MaxTotalSize > 0 && total+file.size > f.opts.MaxTotalSize)
+		if remove {
+			if err := removeIfExists(file.path); err != nil {
+				return err
+			}
+			continue
+		}
+		kept++
+		total += file.size
+	}
+	return nil
+}
+
+// rotatedFiles returns all files in the directory of the current
+// file, that were created by rotating this file.
+// The caller must hold the mill lock.
+func (f *RotatingFile) rotatedFiles() ([]rotatedFile, error) {
+	current := f.path
+	dir := filepath.Dir(current)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []rotatedFile
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		file, ok := f.parseRotated(entry.Name(), filepath.Base(current))
+		if !ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		file.path = filepath.Join(dir, entry.Name())
+		file.modTime = info.ModTime()
+		file.size = info.Size()
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// parseRotated returns the rotated file with the given name and
+// true, if the file was created by rotating this file, i.e. if it
+// is a (compressed) numbered backup or, if the file is rotated by
+// time, the file of a past interval.
+// Path, modification time and size of the returned file are not set.
+func (f *RotatingFile) parseRotated(name, current string) (rotatedFile, bool) {
+	var file rotatedFile
+	if name == current {
+		return file, false
+	}
+
+	candidate := strings.TrimSuffix(name, ".gz")
+	if i := strings.LastIndexByte(candidate, '.'); i >= 0 {
+		if n, err := strconv.Atoi(candidate[i+1:]); err == nil && n > 0 {
+			candidate = candidate[:i]
+			file.n = n
+		}
+	}
+
+	if f.pattern == "" {
+		return file, file.n > 0 && candidate == current
+	}
+	start, err := time.Parse(filepath.Base(f.pattern), candidate)
+	file.start = start
+	return file, err == nil
+}
+
+// compressFile compresses the file at the given path into
+// <path>.gz, keeping the modification time, and removes the
+// original file.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	info, err := src.Stat()
+	if err != nil {
+		src.Close()
+		return err
+	}
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		src.Close()
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	// the source is closed before it is removed, which would fail
+	// on Windows otherwise
+	src.Close()
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+".gz")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func renameIfExists(from, to string) error {
+	if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,381 @@
+package abc
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotatingFile_Rotation(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+
+	f, err := NewRotatingFile(path, RotatingFileOptions{
+		MaxSize:    10,
+		MaxBackups: 2,
+	})
+	assert.NoError(err)
+	defer f.Close()
+
+	_, _ = f.Write([]byte("aaaa\n"))
+	_, _ = f.Write([]byte("bbbb\n")) // exactly 10 bytes
+	assert.Equal("aaaa\nbbbb\n", readFile(path))
+
+	_, _ = f.Write([]byte("cccc\n")) // rotates
+	assert.Equal("cccc\n", readFile(path))
+	assert.Equal("aaaa\nbbbb\n", readFile(path+".1"))
+
+	_, _ = f.Write([]byte("this line is too long\n")) // rotates and is written completely
+	_, _ = f.Write([]byte("dddd\n"))                  // rotates
+	assert.Equal("dddd\n", readFile(path))
+	assert.Equal("this line is too long\n", readFile(path+".1"))
+	assert.Equal("cccc\n", readFile(path+".2"))
+	_, err = os.Stat(path + ".3")
+	assert.True(os.IsNotExist(err), "Only MaxBackups backups must be kept")
+}
+
+func TestRotatingFile_NoBackups(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	f, err := NewRotatingFile(path, RotatingFileOptions{MaxSize: 5})
+	assert.NoError(err)
+	defer f.Close()
+
+	_, _ = f.Write([]byte("aaaa\n"))
+	_, _ = f.Write([]byte("bbbb\n"))
+
+	content, _ := ioutil.ReadFile(path)
+	assert.Equal("bbbb\n", string(content))
+	_, err = os.Stat(path + ".1")
+	assert.True(os.IsNotExist(err), "No backups must be kept")
+}
+
+func TestRotatingFile_Append(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	assert.NoError(ioutil.WriteFile(path, []byte("existing\n"), 0644))
+
+	f, err := NewRotatingFile(path, RotatingFileOptions{MaxSize: 15, MaxBackups: 1})
+	assert.NoError(err)
+	defer f.Close()
+
+	_, _ = f.Write([]byte("new line\n")) // existing size must be respected
+	content, _ := ioutil.ReadFile(path)
+	assert.Equal("new line\n", string(content))
+	content, _ = ioutil.ReadFile(path + ".1")
+	assert.Equal("existing\n", string(content))
+}
+
+func TestRotatingFile_Concurrent(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	f, err := NewRotatingFile(path, RotatingFileOptions{MaxSize: 1024, MaxBackups: 1000})
+	assert.NoError(err)
+
+	logger := newTestSimpleLogger(LevelInfo, f)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				logger.With("goroutine", i).Infof("line %v", j)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.NoError(f.Close())
+
+	files, _ := filepath.Glob(filepath.Join(dir, "app.log*"))
+	assert.True(len(files) > 1, "File was never rotated")
+
+	lines := 0
+	for _, file := range files {
+		content, _ := ioutil.ReadFile(file)
+		assert.True(len(content) <= 1024, "File %v exceeds the maximum size", file)
+		for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+			assert.Regexp(`^0001-01-01 00:00:00.000 \[INFO\] - line \d+ goroutine=\d$`, line, fmt.Sprintf("Line in %v was split", file))
+			lines++
+		}
+	}
+	assert.Equal(1000, lines, "Lines were lost")
+}
+
+func TestRotatingFile_Closed(t *testing.T) {
+	assert := assert.New(t)
+
+	f, err := NewRotatingFile(filepath.Join(t.TempDir(), "app.log"), RotatingFileOptions{})
+	assert.NoError(err)
+	assert.NoError(f.Close())
+
+	_, err = f.Write([]byte("abc"))
+	assert.Equal(os.ErrClosed, err)
+	assert.Equal(os.ErrClosed, f.Rotate())
+}
+
+func TestRotatingFile_RotationFails(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	f, err := NewRotatingFile(path, RotatingFileOptions{MaxSize: 5, MaxBackups: 1})
+	assert.NoError(err)
+	defer f.Close()
+
+	// a non-empty directory can neither be removed nor replaced
+	assert.NoError(os.MkdirAll(filepath.Join(path+".1", "dir"), 0755))
+
+	_, _ = f.Write([]byte("aaaa\n"))
+	n, err := f.Write([]byte("bbbb\n")) // rotation fails
+	assert.NoError(err)
+	assert.Equal(5, n)
+	assert.Equal("aaaa\nbbbb\n", readFile(path), "Writes must go to the current file")
+	assert.Error(f.Rotate())
+
+	assert.NoError(os.RemoveAll(path + ".1"))
+	_, _ = f.Write([]byte("cccc\n")) // rotation is tried again
+	assert.Equal("cccc\n", readFile(path))
+	assert.Equal("aaaa\nbbbb\n", readFile(path+".1"))
+}
+
+func TestRotatingFile_OpenFails(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	clk := &manualClock{now: time.Date(2018, 11, 24, 12, 0, 0, 0, time.UTC)}
+
+	f, err := newRotatingFile(filepath.Join(dir, "app-2006-01-02.log"), RotatingFileOptions{
+		Interval: RotateDaily,
+		MaxAge:   7 * 24 * time.Hour,
+	}, clk)
+	assert.NoError(err)
+
+	// the file of the next day cannot be opened
+	assert.NoError(os.Mkdir(filepath.Join(dir, "app-2018-11-25.log"), 0755))
+
+	_, _ = f.Write([]byte("first\n"))
+	clk.Set(time.Date(2018, 11, 25, 12, 0, 0, 0, time.UTC))
+	_, err = f.Write([]byte("second\n"))
+	assert.Error(err)
+
+	assert.NoError(os.Remove(filepath.Join(dir, "app-2018-11-25.log")))
+	_, err = f.Write([]byte("third\n")) // the file is opened again
+	assert.NoError(err)
+
+	assert.NoError(f.Close(), "Close must succeed after a failed rotation")
+	assert.Equal(os.ErrClosed, f.Close())
+	_, err = f.Write([]byte("fourth\n"))
+	assert.Equal(os.ErrClosed, err)
+
+	assert.Equal("first\n", readFile(filepath.Join(dir, "app-2018-11-24.log")))
+	assert.Equal("third\n", readFile(filepath.Join(dir, "app-2018-11-25.log")))
+}
+
+func TestRotatingFile_Daily(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	clk := &manualClock{now: time.Date(2018, 11, 24, 23, 59, 59, 0, time.UTC)}
+
+	f, err := newRotatingFile(filepath.Join(dir, "app-2006-01-02.log"), RotatingFileOptions{Interval: RotateDaily}, clk)
+	assert.NoError(err)
+	defer f.Close()
+
+	assert.Equal(filepath.Join(dir, "app-2018-11-24.log"), f.Path())
+	_, _ = f.Write([]byte("first\n"))
+
+	clk.Set(time.Date(2018, 11, 25, 0, 0, 0, 0, time.UTC))
+	_, _ = f.Write([]byte("second\n"))
+	_, _ = f.Write([]byte("third\n"))
+	assert.Equal(filepath.Join(dir, "app-2018-11-25.log"), f.Path())
+
+	clk.Set(time.Date(2018, 11, 27, 12, 0, 0, 0, time.UTC))
+	_, _ = f.Write([]byte("fourth\n"))
+
+	assert.Equal("first\n", readFile(filepath.Join(dir, "app-2018-11-24.log")))
+	assert.Equal("second\nthird\n", readFile(filepath.Join(dir, "app-2018-11-25.log")))
+	assert.Equal("fourth\n", readFile(filepath.Join(dir, "app-2018-11-27.log")))
+}
+
+func TestRotatingFile_DailyWithMaxSize(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	now := time.Date(2018, 11, 24, 12, 0, 0, 0, time.UTC)
+
+	f, err := newRotatingFile(filepath.Join(dir, "app-2006-01-02.log"), RotatingFileOptions{
+		MaxSize:  20,
+		Interval: RotateDaily,
+		MaxAge:   30 * 24 * time.Hour,
+		Compress: true,
+	}, &manualClock{now: now})
+	assert.NoError(err)
+
+	for _, line := range []string{"first line 0001\n", "second line 002\n", "third line 0003\n"} {
+		_, err = f.Write([]byte(line))
+		assert.NoError(err)
+	}
+	assert.NoError(f.Close())
+
+	path := filepath.Join(dir, "app-2018-11-24.log")
+	assert.Equal("third line 0003\n", readFile(path))
+	assert.Equal("second line 002\n", readGzipFile(path+".1.gz"))
+	assert.Equal("first line 0001\n", readGzipFile(path+".2.gz"))
+}
+
+func TestRotatingFile_Hourly(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	clk := &manualClock{now: time.Date(2018, 11, 24, 15, 30, 0, 0, time.UTC)}
+
+	f, err := newRotatingFile(filepath.Join(dir, "app-2006-01-02T15.log"), RotatingFileOptions{Interval: RotateHourly}, clk)
+	assert.NoError(err)
+	defer f.Close()
+
+	_, _ = f.Write([]byte("first\n"))
+	clk.Set(time.Date(2018, 11, 24, 15, 59, 59, 0, time.UTC))
+	_, _ = f.Write([]byte("second\n"))
+	clk.Set(time.Date(2018, 11, 24, 16, 0, 0, 0, time.UTC))
+	_, _ = f.Write([]byte("third\n"))
+
+	assert.Equal("first\nsecond\n", readFile(filepath.Join(dir, "app-2018-11-24T15.log")))
+	assert.Equal("third\n", readFile(filepath.Join(dir, "app-2018-11-24T16.log")))
+}
+
+func TestRotatingFile_InvalidPattern(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewRotatingFile(filepath.Join(t.TempDir(), "app-2006-01-02.log"), RotatingFileOptions{Interval: RotateHourly})
+	assert.Error(err, "Hourly rotation needs the hour in the file name")
+	_, err = NewRotatingFile(filepath.Join(t.TempDir(), "app.log"), RotatingFileOptions{Interval: RotateDaily})
+	assert.Error(err, "Daily rotation needs the date in the file name")
+}
+
+func TestRotatingFile_Compress(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	f, err := NewRotatingFile(path, RotatingFileOptions{MaxSize: 5, MaxBackups: 3, Compress: true})
+	assert.NoError(err)
+
+	_, _ = f.Write([]byte("aaaa\n"))
+	_, _ = f.Write([]byte("bbbb\n"))
+	_, _ = f.Write([]byte("cccc\n"))
+	assert.NoError(f.Close()) // waits for the compression
+
+	assert.Equal("cccc\n", readFile(path))
+	assert.Equal("bbbb\n", readGzipFile(path+".1.gz"))
+	assert.Equal("aaaa\n", readGzipFile(path+".2.gz"))
+	_, err = os.Stat(path + ".1")
+	assert.True(os.IsNotExist(err), "Compressed files must be removed")
+}
+
+func TestRotatingFile_MaxFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	f, err := NewRotatingFile(path, RotatingFileOptions{MaxSize: 5, MaxBackups: 10, MaxFiles: 2})
+	assert.NoError(err)
+
+	for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n", "dddd\n", "eeee\n"} {
+		_, _ = f.Write([]byte(line))
+	}
+	assert.NoError(f.Close())
+
+	files, _ := filepath.Glob(path + "*")
+	assert.Equal([]string{path, path + ".1", path + ".2"}, files)
+	assert.Equal("eeee\n", readFile(path))
+	assert.Equal("dddd\n", readFile(path+".1"))
+	assert.Equal("cccc\n", readFile(path+".2"))
+}
+
+func TestRotatingFile_MaxTotalSize(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	f, err := NewRotatingFile(path, RotatingFileOptions{MaxSize: 5, MaxBackups: 10, MaxTotalSize: 12})
+	assert.NoError(err)
+
+	for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n", "dddd\n"} {
+		_, _ = f.Write([]byte(line))
+	}
+	assert.NoError(f.Close())
+
+	files, _ := filepath.Glob(path + "*")
+	assert.Equal([]string{path, path + ".1", path + ".2"}, files, "Rotated files must not exceed 12 bytes")
+}
+
+func TestRotatingFile_MaxAge(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	now := time.Date(2018, 11, 24, 12, 0, 0, 0, time.UTC)
+	for name, modTime := range map[string]time.Time{
+		"app-2018-11-01.log":    now.Add(-23 * 24 * time.Hour),
+		"app-2018-11-20.log.gz": now.Add(-4 * 24 * time.Hour),
+		"app-2018-11-21.log":    now.Add(-3 * 24 * time.Hour),
+		"other.log":             now.Add(-100 * 24 * time.Hour),
+	} {
+		assert.NoError(ioutil.WriteFile(filepath.Join(dir, name), []byte("old\n"), 0644))
+		assert.NoError(os.Chtimes(filepath.Join(dir, name), modTime, modTime))
+	}
+
+	f, err := newRotatingFile(filepath.Join(dir, "app-2006-01-02.log"), RotatingFileOptions{
+		Interval: RotateDaily,
+		MaxAge:   7 * 24 * time.Hour,
+	}, &manualClock{now: now})
+	assert.NoError(err)
+	assert.NoError(f.Close())
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for i := range files {
+		files[i] = filepath.Base(files[i])
+	}
+	assert.Equal([]string{"app-2018-11-20.log.gz", "app-2018-11-21.log", "app-2018-11-24.log", "other.log"}, files)
+}
+
+func readFile(path string) string {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "<" + err.Error() + ">"
+	}
+	return string(content)
+}
+
+func readGzipFile(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return "<" + err.Error() + ">"
+	}
+	defer file.Close()
+
+	r, err := gzip.NewReader(file)
+	if err != nil {
+		return "<" + err.Error() + ">"
+	}
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "<" + err.Error() + ">"
+	}
+	return string(content)
+}
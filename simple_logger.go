@@ -1,9 +1,7 @@
 package abc
 
 import (
-	"fmt"
-	"io"
-	"sync"
+	"bytes"
 )
 
 const (
@@ -12,155 +10,89 @@ const (
 	TimeLayoutSimpleLogger = "2006-01-02 15:04:05.000"
 )
 
+var simpleTimestamps = &timestampCache{layout: TimeLayoutSimpleLogger}
+
 // SimpleLogger is a logger that prints log messages.
 // SimpleLoggers are completely safe for concurrent use.
 type SimpleLogger struct {
-	lvlMux sync.Mutex
-	lvl    LogLevel
-
-	clockMux sync.Mutex
-	clk      clock
-
-	outMux sync.Mutex
-	out    io.Writer
-}
-
-// Print prints the given values with the given log level,
-// if and only if the given log level is higher than or
-// equal to the one of this logger.
-func (s *SimpleLogger) Print(lvl LogLevel, v ...interface{}) {
-	if s.IsLevelEnabled(lvl) {
-		s.print0(s.prepareMessage(lvl, fmt.Sprint(v...)))
-	}
-}
-
-// Printf formats and prints the given values with the given log level,
-// if and only if the given log level is higher than or
-// equal to the one of this logger.
-func (s *SimpleLogger) Printf(lvl LogLevel, format string, v ...interface{}) {
-	if s.IsLevelEnabled(lvl) {
-		s.print0(s.prepareMessage(lvl, fmt.Sprintf(format, v...)))
-	}
-}
-
-func (s *SimpleLogger) prepareMessage(lvl LogLevel, a string) string {
-	return fmt.Sprintf("%v [%-4v] - %v\n", s.clk.Now().Format(TimeLayoutSimpleLogger), lvl.String(), a)
-}
-
-func (s *SimpleLogger) print0(a string) {
-	io.WriteString(s.out, a)
-}
-
-// Verbose prints the given values with log level DEBG,
-// if and only if this logger has the verbose log level enabled.
-func (s *SimpleLogger) Verbose(v ...interface{}) {
-	s.Print(LevelVerbose, v...)
-}
-
-// Verbosef formats and prints the given values with log level DEBG,
-// if and only if this logger has the verbose log level enabled.
-func (s *SimpleLogger) Verbosef(format string, v ...interface{}) {
-	s.Printf(LevelVerbose, format, v...)
-}
-
-// Debug prints the given values with log level DEBG.
-func (s *SimpleLogger) Debug(v ...interface{}) {
-	s.Print(LevelDebug, v...)
-}
-
-// Debugf formats and prints the given values with log level DEBG.
-func (s *SimpleLogger) Debugf(format string, v ...interface{}) {
-	s.Printf(LevelDebug, format, v...)
-}
-
-// Info prints the given values with log level INFO.
-func (s *SimpleLogger) Info(v ...interface{}) {
-	s.Print(LevelInfo, v...)
-}
-
-// Infof formats and prints the given values with log level INFO.
-func (s *SimpleLogger) Infof(format string, v ...interface{}) {
-	s.Printf(LevelInfo, format, v...)
-}
-
-// Warn prints the given values with log level WARN.
-func (s *SimpleLogger) Warn(v ...interface{}) {
-	s.Print(LevelWarn, v...)
-}
-
-// Warnf formats and prints the given values with log level WARN.
-func (s *SimpleLogger) Warnf(format string, v ...interface{}) {
-	s.Printf(LevelWarn, format, v...)
-}
-
-// Error prints the given values with log level ERR.
-func (s *SimpleLogger) Error(v ...interface{}) {
-	s.Print(LevelError, v...)
-}
-
-// Errorf formats and prints the given values with log level ERR.
-func (s *SimpleLogger) Errorf(format string, v ...interface{}) {
-	s.Printf(LevelError, format, v...)
+	writerCore
 }
 
-// Fatal prints the given values with log level FATAL.
-// IT DOES NOT TERMINATE THE APPLICATION.
-func (s *SimpleLogger) Fatal(v ...interface{}) {
-	s.Print(LevelFatal, v...)
+// simpleFormatter is the formatter of the SimpleLogger.
+type simpleFormatter struct {
+	caller CallerMode
 }
 
-// Fatalf formats and prints the given values with log level FATAL.
-// IT DOES NOT TERMINATE THE APPLICATION.
-func (s *SimpleLogger) Fatalf(format string, v ...interface{}) {
-	s.Printf(LevelFatal, format, v...)
+func (f simpleFormatter) Format(buf *bytes.Buffer, rec *Record) error {
+	return f.formatColored(buf, rec, nil)
 }
 
-// Level returns the current level of this logger.
-func (s *SimpleLogger) Level() LogLevel {
-	s.lvlMux.Lock()
-	defer s.lvlMux.Unlock()
-
-	return s.lvl
+// formatColored colors the level.
+func (f simpleFormatter) formatColored(buf *bytes.Buffer, rec *Record, colors []color) error {
+	simpleTimestamps.write(buf, rec.Time)
+	buf.WriteByte(' ')
+	startColor(buf, colors, rec.Level)
+	buf.WriteByte('[')
+	writePaddedLevel(buf, rec.Level)
+	buf.WriteByte(']')
+	endColor(buf, colors)
+	writeCaller(buf, f.caller, rec)
+	buf.WriteString(" - ")
+	buf.WriteString(rec.Message)
+	rec.Fields.writeSuffix(buf)
+	buf.WriteByte('\n')
+	return nil
 }
 
-// SetLevel changes the log level of this logger.
-func (s *SimpleLogger) SetLevel(lvl LogLevel) {
-	s.lvlMux.Lock()
-	defer s.lvlMux.Unlock()
-	s.lvl = lvl
+// With returns a new SimpleLogger that prints the given key/value
+// pairs after the message of every log line, in addition to the
+// fields of this logger.
+// The new logger starts with the level, clock and writer of this logger.
+func (s *SimpleLogger) With(keyvals ...interface{}) Logger {
+	l := &SimpleLogger{}
+	s.derive(&l.writerCore, keyvals...)
+	return l
 }
 
-func (s *SimpleLogger) SetLevelString(level string) {
-	s.SetLevel(ToLogLevel(level))
+// SetCallerMode sets the way in which this logger prints the caller
+// of every message, between the level and the message, e.g.
+//
+//	2018-11-24 15:26:44.453 [INFO] main.go:16 - Hello World!
+//
+// The caller is not printed with CallerNone, which is the default.
+func (s *SimpleLogger) SetCallerMode(mode CallerMode) {
+	s.setFormatter(simpleFormatter{caller: mode})
+	s.setRecordsCaller(mode != CallerNone)
 }
 
-// IsLevelEnabled returns true if and only if this logger would print
-// messages with the given log level.
-// False otherwise.
-func (s *SimpleLogger) IsLevelEnabled(lvl LogLevel) bool {
-	return lvl >= s.Level()
+// ColorMode returns the color mode of this logger.
+func (s *SimpleLogger) ColorMode() ColorMode {
+	return s.getColorMode()
 }
 
-// clock returns the clock of this logger.
-func (s *SimpleLogger) clock() clock {
-	return s.clk
+// SetColorMode sets the color mode of this logger, which controls
+// whether the level is printed in the color of the level, e.g.
+// with ColorModeAuto, only if the writer is a terminal.
+// The color mode is described at ColoredLogger, which colors
+// whole lines instead.
+// No colors are printed with ColorModeNever, which is the default.
+func (s *SimpleLogger) SetColorMode(mode ColorMode) {
+	s.setColorMode(mode)
 }
 
-// SetClock sets a new clock for this logger.
-func (s *SimpleLogger) SetClock(clk clock) {
-	s.clockMux.Lock()
-	defer s.clockMux.Unlock()
-	s.clk = clk
+// Theme returns a copy of the color theme of this logger.
+func (s *SimpleLogger) Theme() ColorTheme {
+	return s.getTheme()
 }
 
-// Out returns the writer of this logger.
-func (s *SimpleLogger) Out() io.Writer {
-	return s.out
+// SetTheme sets the color theme, with which the level is colored.
+// The theme is copied, so later changes of the given theme
+// have no effect.
+func (s *SimpleLogger) SetTheme(theme ColorTheme) {
+	s.setTheme(theme)
 }
 
-// SetOut sets a new writer for this logger.
-func (s *SimpleLogger) SetOut(out io.Writer) {
-	s.outMux.Lock()
-	defer s.outMux.Unlock()
-	s.out = out
+// ColorsEnabled returns whether colors are currently printed.
+func (s *SimpleLogger) ColorsEnabled() bool {
+	return s.colorsEnabled()
 }
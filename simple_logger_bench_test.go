@@ -5,11 +5,7 @@ import (
 )
 
 func BenchmarkSimpleLogger_Printf(b *testing.B) {
-	logger := &SimpleLogger{
-		clk: &mockClock{},
-		lvl: LevelVerbose,
-		out: &MockWriter{},
-	}
+	logger := newTestSimpleLogger(LevelVerbose, &MockWriter{})
 	b.ReportAllocs()
 	b.ResetTimer()
 	b.RunParallel(func(pb *testing.PB) {
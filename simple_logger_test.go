@@ -2,6 +2,7 @@ package abc
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
 
 	"github.com/sergi/go-diff/diffmatchpatch"
@@ -11,11 +12,7 @@ import (
 func TestSimpleLogger_Printf(t *testing.T) {
 	buf := &bytes.Buffer{}
 
-	logger := &SimpleLogger{
-		clk: &mockClock{},
-		lvl: LevelDebug,
-		out: buf,
-	}
+	logger := newTestSimpleLogger(LevelDebug, buf)
 
 	type args struct {
 		lvl    LogLevel
@@ -80,11 +77,7 @@ func TestSimpleLogger_Printf(t *testing.T) {
 func TestSimpleLogger_Print(t *testing.T) {
 	buf := &bytes.Buffer{}
 
-	logger := &SimpleLogger{
-		clk: &mockClock{},
-		lvl: LevelDebug,
-		out: buf,
-	}
+	logger := newTestSimpleLogger(LevelDebug, buf)
 
 	type args struct {
 		lvl LogLevel
@@ -185,11 +178,7 @@ func TestSimpleLogger_All_Outputs(t *testing.T) {
 
 	buf := &bytes.Buffer{}
 
-	logger := &SimpleLogger{
-		clk: &mockClock{},
-		lvl: LevelVerbose,
-		out: buf,
-	}
+	logger := newTestSimpleLogger(LevelVerbose, buf)
 
 	check := func() {
 		defer func() {
@@ -246,11 +235,7 @@ func TestSimpleLogger_SetOut(t *testing.T) {
 	buf1 := &bytes.Buffer{}
 	buf2 := &bytes.Buffer{}
 
-	logger := &SimpleLogger{
-		clk: &mockClock{},
-		lvl: LevelVerbose,
-		out: buf1,
-	}
+	logger := newTestSimpleLogger(LevelVerbose, buf1)
 
 	logger.Info("foo")
 	assert.Equal("0001-01-01 00:00:00.000 [INFO] - foo\n", buf1.String(), "buf1 did receive wrong output.")
@@ -280,11 +265,7 @@ func TestSimpleLogger_SetLevel(t *testing.T) {
 
 	buf := &bytes.Buffer{}
 
-	logger := &SimpleLogger{
-		clk: &mockClock{},
-		lvl: LevelVerbose,
-		out: buf,
-	}
+	logger := newTestSimpleLogger(LevelVerbose, buf)
 
 	logger.Verbose("foo")
 	assert.Equal("0001-01-01 00:00:00.000 [DEBG] - foo\n", buf.String(), "buf did receive wrong output.")
@@ -295,3 +276,83 @@ func TestSimpleLogger_SetLevel(t *testing.T) {
 	logger.Verbose("foo")
 	assert.Equal("", buf.String(), "buf did receive output.")
 }
+
+func TestSimpleLogger_With(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	logger := newTestSimpleLogger(LevelVerbose, buf)
+
+	derived := logger.With("request", 17, "user", "John Doe")
+	derived.Info("abc")
+	assert.Equal("0001-01-01 00:00:00.000 [INFO] - abc request=17 user=\"John Doe\"\n", buf.String(), "buf did receive wrong output.")
+
+	buf.Reset()
+
+	derived.(FieldLogger).With("step", "two").Debug("abc")
+	assert.Equal("0001-01-01 00:00:00.000 [DEBG] - abc request=17 user=\"John Doe\" step=two\n", buf.String(), "buf did receive wrong output.")
+
+	buf.Reset()
+
+	logger.Info("abc") // original logger must not print fields
+	assert.Equal("0001-01-01 00:00:00.000 [INFO] - abc\n", buf.String(), "buf did receive wrong output.")
+
+	buf.Reset()
+
+	derived.SetLevel(LevelWarn) // must not affect the original logger
+	logger.Info("abc")
+	assert.Equal("0001-01-01 00:00:00.000 [INFO] - abc\n", buf.String(), "buf did receive wrong output.")
+}
+
+func TestSimpleLogger_SetCallerMode(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	logger := newTestSimpleLogger(LevelInfo, buf)
+
+	logger.SetCallerMode(CallerFile)
+	line := nextLine()
+	logger.With("key", "value").Info("file")
+	logger.SetCallerMode(CallerFunction)
+	logger.Info("function")
+	logger.SetCallerMode(CallerNone)
+	logger.Info("none")
+
+	assert.Equal(""+
+		fmt.Sprintf("0001-01-01 00:00:00.000 [INFO] simple_logger_test.go:%v - file key=value\n", line)+
+		"0001-01-01 00:00:00.000 [INFO] abc.TestSimpleLogger_SetCallerMode - function\n"+
+		"0001-01-01 00:00:00.000 [INFO] - none\n", buf.String())
+
+	buf.Reset()
+	logger.SetCallerMode(CallerFile)
+	defer SetRoot(Root())
+	SetRoot(logger)
+	line = nextLine()
+	Warn("root")
+	assert.Equal(fmt.Sprintf("0001-01-01 00:00:00.000 [WARN] simple_logger_test.go:%v - root\n", line), buf.String())
+}
+
+func TestSimpleLogger_SetColorMode(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	logger := newTestSimpleLogger(LevelInfo, buf)
+	assert.Equal(ColorModeNever, logger.ColorMode(), "Colors must be disabled by default")
+
+	logger.SetColorMode(ColorModeAlways)
+	logger.Info("abc")
+	logger.With("request", 17).Warn("abc")
+	assert.Equal("0001-01-01 00:00:00.000 "+string(ColorGreen)+"[INFO]"+string(ColorReset)+" - abc\n"+
+		"0001-01-01 00:00:00.000 "+string(ColorYellow)+"[WARN]"+string(ColorReset)+" - abc request=17\n", buf.String())
+
+	buf.Reset()
+	logger.SetTheme(ColorTheme{LevelInfo: {Foreground: Color256(39)}})
+	logger.Info("abc")
+	assert.Equal("0001-01-01 00:00:00.000 \u001b[38;5;39m[INFO]"+string(ColorReset)+" - abc\n", buf.String())
+
+	buf.Reset()
+	logger.SetColorMode(ColorModeAuto)
+	logger.Info("abc")
+	assert.Equal("0001-01-01 00:00:00.000 [INFO] - abc\n", buf.String(), "No color codes must be written to a buffer")
+}
@@ -0,0 +1,127 @@
+package abc
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Levels of log/slog that correspond to LevelVerbose and LevelFatal,
+// which have no counterpart in log/slog.
+const (
+	SlogLevelVerbose = slog.LevelDebug - 4
+	SlogLevelFatal   = slog.LevelError + 4
+)
+
+// FromSlogLevel converts a log/slog level to a LogLevel.
+// Levels between the predefined levels are rounded down,
+// levels below slog.LevelDebug are LevelVerbose and levels
+// at or above SlogLevelFatal are LevelFatal.
+func FromSlogLevel(lvl slog.Level) LogLevel {
+	switch {
+	case lvl < slog.LevelDebug:
+		return LevelVerbose
+	case lvl < slog.LevelInfo:
+		return LevelDebug
+	case lvl < slog.LevelWarn:
+		return LevelInfo
+	case lvl < slog.LevelError:
+		return LevelWarn
+	case lvl < SlogLevelFatal:
+		return LevelError
+	}
+	return LevelFatal
+}
+
+// SlogHandler is a slog.Handler that passes all records
+// to an abc.Logger.
+// Attributes become fields of the abc record, and groups
+// are flattened into dot separated keys, e.g. the attribute
+// "id" in the group "request" becomes the field "request.id".
+//
+// If the logger is a RecordLogger, the time and the caller of
+// the slog record are preserved, so the output is identical
+// to that of a direct call to the logger.
+type SlogHandler struct {
+	logger Logger
+	// prefix is the group prefix for attributes, including
+	// a trailing dot, or an empty string.
+	prefix string
+	fields Fields
+}
+
+// Enabled returns true if and only if the logger of this
+// handler has the corresponding LogLevel enabled.
+func (h *SlogHandler) Enabled(_ context.Context, lvl slog.Level) bool {
+	return h.logger.IsLevelEnabled(FromSlogLevel(lvl))
+}
+
+// Handle converts the given record to an abc record and
+// logs it with the logger of this handler.
+func (h *SlogHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := make(Fields, len(h.fields), len(h.fields)+r.NumAttrs())
+	copy(fields, h.fields)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendSlogAttr(fields, h.prefix, a)
+		return true
+	})
+
+	return logRecord(h.logger, &Record{
+		Time:    r.Time,
+		Level:   FromSlogLevel(r.Level),
+		Message: r.Message,
+		PC:      r.PC,
+		Fields:  fields,
+	})
+}
+
+// WithAttrs returns a new handler that adds the given
+// attributes to every record.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(Fields, len(h.fields), len(h.fields)+len(attrs))
+	copy(fields, h.fields)
+	for _, a := range attrs {
+		fields = appendSlogAttr(fields, h.prefix, a)
+	}
+
+	return &SlogHandler{
+		logger: h.logger,
+		prefix: h.prefix,
+		fields: fields,
+	}
+}
+
+// WithGroup returns a new handler that puts all following
+// attributes into the given group.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &SlogHandler{
+		logger: h.logger,
+		prefix: h.prefix + name + ".",
+		fields: h.fields,
+	}
+}
+
+// appendSlogAttr appends the given attribute to the fields,
+// flattening groups and dropping empty attributes, as described
+// in the documentation of slog.Handler.
+func appendSlogAttr(fields Fields, prefix string, a slog.Attr) Fields {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendSlogAttr(fields, prefix, ga)
+		}
+		return fields
+	}
+
+	return append(fields, Field{prefix + a.Key, a.Value.Any()})
+}
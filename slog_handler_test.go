@@ -0,0 +1,119 @@
+package abc
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromSlogLevel(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(LevelVerbose, FromSlogLevel(SlogLevelVerbose))
+	assert.Equal(LevelVerbose, FromSlogLevel(slog.LevelDebug-1))
+	assert.Equal(LevelDebug, FromSlogLevel(slog.LevelDebug))
+	assert.Equal(LevelDebug, FromSlogLevel(slog.LevelInfo-1))
+	assert.Equal(LevelInfo, FromSlogLevel(slog.LevelInfo))
+	assert.Equal(LevelWarn, FromSlogLevel(slog.LevelWarn))
+	assert.Equal(LevelError, FromSlogLevel(slog.LevelError))
+	assert.Equal(LevelError, FromSlogLevel(SlogLevelFatal-1))
+	assert.Equal(LevelFatal, FromSlogLevel(SlogLevelFatal))
+	assert.Equal(LevelFatal, FromSlogLevel(SlogLevelFatal+100))
+}
+
+func TestSlogHandler_SimpleLogger(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+	expected := &bytes.Buffer{}
+
+	logger := newTestSimpleLogger(LevelVerbose, buf)
+	direct := newTestSimpleLogger(LevelVerbose, expected)
+	slogger := slog.New(NewSlogHandler(logger))
+
+	// slog records without time use the clock of the logger
+	log := func(lvl slog.Level, msg string, args ...interface{}) {
+		r := slog.NewRecord(time.Time{}, lvl, msg, 0)
+		r.Add(args...)
+		_ = slogger.Handler().Handle(context.Background(), r)
+	}
+
+	log(SlogLevelVerbose, "abc")
+	direct.Verbose("abc")
+	log(slog.LevelDebug, "abc")
+	direct.Debug("abc")
+	log(slog.LevelInfo, "abc", "request", 17)
+	direct.With("request", 17).Info("abc")
+	log(slog.LevelWarn, "abc")
+	direct.Warn("abc")
+	log(slog.LevelError, "abc")
+	direct.Error("abc")
+	log(SlogLevelFatal, "abc")
+	direct.Fatal("abc")
+
+	assert.Equal(expected.String(), buf.String(), "Output must be identical to direct calls")
+}
+
+func TestSlogHandler_AttrsAndGroups(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	logger := newTestNamedLogger("MyLogger", LevelInfo, buf)
+	slogger := slog.New(NewSlogHandler(logger.With("app", "test"))).
+		With("a", 1).
+		WithGroup("request").
+		With("id", 17)
+
+	slogger.Info("abc",
+		"method", "GET",
+		slog.Group("user", "name", "John Doe", slog.Group("", "admin", true)),
+		slog.Group("empty"),
+		slog.Attr{},
+	)
+	assert.Regexp(`^\d{4}-\d\d-\d\d \d\d:\d\d:\d\d\.\d{3} <MyLogger> \[INFO\] - abc app=test a=1 request.id=17 request.method=GET request.user.name="John Doe" request.user.admin=true\n$`, buf.String())
+}
+
+func TestSlogHandler_Enabled(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	logger := newTestSimpleLogger(LevelWarn, buf)
+	handler := NewSlogHandler(logger)
+
+	assert.False(handler.Enabled(context.Background(), slog.LevelInfo))
+	assert.True(handler.Enabled(context.Background(), slog.LevelWarn))
+
+	slog.New(handler).Info("suppressed")
+	assert.Empty(buf.String(), "buf did receive output.")
+
+	logger.SetLevel(LevelVerbose)
+	assert.True(handler.Enabled(context.Background(), SlogLevelVerbose))
+}
+
+func TestSlogHandler_Caller(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	logger := newTestCustomPatternLogger(`{{.Filef "short"}}:{{.Line}}`, LevelInfo, buf)
+	slog.New(NewSlogHandler(logger)).Info("abc") // this is line 105
+	assert.Equal("slog_handler_test.go:105", buf.String())
+}
+
+func TestSlogHandler_ColoredLogger(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	logger := newTestColoredLogger(newTestSimpleLogger(LevelInfo, buf))
+	r := slog.NewRecord(time.Time{}, slog.LevelWarn, "abc", 0)
+	r.Add("request", 17)
+	_ = NewSlogHandler(logger).Handle(context.Background(), r)
+	assert.Equal(string(ColorYellow)+"0001-01-01 00:00:00.000 [WARN] - abc request=17\n"+string(ColorReset), buf.String())
+}
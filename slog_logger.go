@@ -0,0 +1,54 @@
+package abc
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogLevelMapping maps LogLevels onto log/slog levels.
+type SlogLevelMapping func(LogLevel) slog.Level
+
+// ToSlogLevel is the default SlogLevelMapping.
+// LevelVerbose is mapped to SlogLevelVerbose and LevelFatal
+// to SlogLevelFatal, all other levels are mapped to their
+// log/slog counterparts.
+// It is the inverse of FromSlogLevel.
+func ToSlogLevel(lvl LogLevel) slog.Level {
+	switch lvl {
+	case LevelVerbose:
+		return SlogLevelVerbose
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	}
+	return SlogLevelFatal
+}
+
+// slogHandler is a LevelHandler that converts records to
+// log/slog records and passes them to a slog.Handler.
+type slogHandler struct {
+	handler slog.Handler
+	mapping SlogLevelMapping
+}
+
+// Enabled returns true if and only if the slog.Handler is
+// enabled for the mapped level.
+func (h *slogHandler) Enabled(lvl LogLevel) bool {
+	return h.handler.Enabled(context.Background(), h.mapping(lvl))
+}
+
+// Handle passes the record to the slog.Handler.
+// The fields of the record become attributes, and the PC of
+// the record is used as source of the slog record.
+func (h *slogHandler) Handle(rec *Record) error {
+	r := slog.NewRecord(rec.Time, h.mapping(rec.Level), rec.Message, rec.PC)
+	for _, field := range rec.Fields {
+		r.AddAttrs(slog.Any(field.Key, field.Value))
+	}
+	return h.handler.Handle(context.Background(), r)
+}
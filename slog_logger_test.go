@@ -0,0 +1,86 @@
+package abc
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToSlogLevel(t *testing.T) {
+	assert := assert.New(t)
+
+	for lvl := LevelVerbose; lvl <= LevelFatal; lvl++ {
+		assert.Equal(lvl, FromSlogLevel(ToSlogLevel(lvl)), "ToSlogLevel must be the inverse of FromSlogLevel")
+	}
+	assert.Equal(SlogLevelVerbose, ToSlogLevel(LevelVerbose))
+	assert.Equal(SlogLevelFatal, ToSlogLevel(LevelFatal))
+}
+
+func TestSlogLogger(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	handler := slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     slog.LevelDebug,
+	})
+	logger := NewSlogLogger(handler, nil)
+
+	logger.Verbose("suppressed by the handler")
+	assert.Empty(buf.String(), "buf did receive output.")
+	assert.False(logger.IsLevelEnabled(LevelVerbose))
+	assert.True(logger.IsLevelEnabled(LevelDebug))
+
+	logger.(FieldLogger).With("request", 17).Warnf("fmt: %v", "abc") // this is line 39
+
+	var obj map[string]interface{}
+	assert.NoError(json.Unmarshal(buf.Bytes(), &obj))
+	assert.Equal("WARN", obj["level"])
+	assert.Equal("fmt: abc", obj["msg"])
+	assert.Equal(float64(17), obj["request"])
+
+	source, _ := obj["source"].(map[string]interface{})
+	assert.Equal("slog_logger_test.go", filepath.Base(source["file"].(string)))
+	assert.Equal(float64(39), source["line"])
+	assert.Equal("github.com/TimSatke/abc.TestSlogLogger", source["function"])
+}
+
+func TestSlogLogger_Mapping(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := &bytes.Buffer{}
+
+	handler := slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	logger := NewSlogLogger(handler, func(lvl LogLevel) slog.Level {
+		switch lvl {
+		case LevelVerbose:
+			return slog.LevelDebug
+		case LevelFatal:
+			return slog.LevelError
+		}
+		return ToSlogLevel(lvl)
+	})
+
+	logger.Verbose("abc")
+	logger.Fatal("abc")
+	assert.Equal("level=DEBUG msg=abc\nlevel=ERROR msg=abc\n", buf.String())
+
+	buf.Reset()
+
+	logger.SetLevel(LevelError)
+	logger.Warn("abc")
+	assert.Empty(buf.String(), "The level of the logger must be respected")
+}
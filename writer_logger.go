@@ -1,6 +1,9 @@
 package abc
 
-import "io"
+import (
+	"io"
+	"os"
+)
 
 // WriterLogger is an interface that embeds abc.Logger.
 // It describes loggers that write their output to
@@ -14,5 +17,37 @@ type WriterLogger interface {
 	Out() io.Writer
 	// SetOut changes the writer to which the output
 	// is printed.
+	// The previous writer is neither synced nor closed.
 	SetOut(io.Writer)
 }
+
+// WriteErrorHandler is implemented by loggers that can report
+// and redirect failed writes.
+// All WriterLoggers of this package are WriteErrorHandlers.
+type WriteErrorHandler interface {
+	// OnWriteError sets a function, that is called with the
+	// error of every failed write.
+	OnWriteError(func(error))
+	// SetFallback sets a writer, to which messages are written,
+	// if the write to the writer of this logger fails.
+	SetFallback(io.Writer)
+	// FailedWrites returns the number of failed writes.
+	FailedWrites() uint64
+}
+
+// Syncer is implemented by writers that can commit their
+// output to stable storage, e.g. *os.File, and by all loggers
+// of this package, which sync their writer.
+// Loggers of this package implement io.Closer as well, and close
+// their writer, unless it is os.Stdout or os.Stderr. Loggers that
+// were derived with With share the writer, so they must not be
+// used after Close.
+type Syncer interface {
+	Sync() error
+}
+
+// isStdStream returns true if the given writer is os.Stdout
+// or os.Stderr, which loggers must neither sync nor close.
+func isStdStream(w io.Writer) bool {
+	return w == os.Stdout || w == os.Stderr
+}
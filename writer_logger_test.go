@@ -0,0 +1,141 @@
+package abc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// syncCloseWriter is a writer that counts calls of Sync and Close.
+type syncCloseWriter struct {
+	bytes.Buffer
+	syncs, closes int
+}
+
+func (w *syncCloseWriter) Sync() error {
+	w.syncs++
+	return nil
+}
+
+func (w *syncCloseWriter) Close() error {
+	w.closes++
+	return errors.New("close error")
+}
+
+// syncCloseLogger is a logger that can be synced and closed.
+type syncCloseLogger interface {
+	WriterLogger
+	Syncer
+	io.Closer
+}
+
+func TestWriterLogger_SyncClose(t *testing.T) {
+	loggers := map[string]func(out *syncCloseWriter) syncCloseLogger{
+		"simple": func(out *syncCloseWriter) syncCloseLogger { return newTestSimpleLogger(LevelInfo, out) },
+		"named":  func(out *syncCloseWriter) syncCloseLogger { return newTestNamedLogger("name", LevelInfo, out) },
+		"json":   func(out *syncCloseWriter) syncCloseLogger { return newTestJSONLogger("name", LevelInfo, out) },
+		"colored": func(out *syncCloseWriter) syncCloseLogger {
+			return NewColoredLogger(newTestSimpleLogger(LevelInfo, out)).(syncCloseLogger)
+		},
+		"async": func(out *syncCloseWriter) syncCloseLogger {
+			return newAsyncLogger(newTestSimpleLogger(LevelInfo, out), AsyncOptions{}, &manualClock{})
+		},
+	}
+	for name, newLogger := range loggers {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			out := &syncCloseWriter{}
+			logger := newLogger(out)
+			logger.Info("message")
+
+			assert.NoError(logger.Sync())
+			assert.Equal(1, out.syncs)
+			assert.Contains(out.String(), "message", "Sync must flush all messages")
+			assert.EqualError(logger.Close(), "close error")
+			assert.Equal(1, out.closes)
+		})
+	}
+}
+
+func TestWriterLogger_SyncCloseStdStreams(t *testing.T) {
+	assert := assert.New(t)
+
+	logger := NewSimpleLogger().(syncCloseLogger)
+	assert.NoError(logger.Sync())
+	assert.NoError(logger.Close())
+	_, err := os.Stdout.Stat()
+	assert.NoError(err, "Stdout must not be closed")
+
+	logger.SetOut(&bytes.Buffer{}) // neither Syncer nor io.Closer
+	assert.NoError(logger.Sync())
+	assert.NoError(logger.Close())
+}
+
+func TestShutdown(t *testing.T) {
+	assert := assert.New(t)
+
+	defer SetRoot(Root())
+
+	out := &syncCloseWriter{}
+	logger := newAsyncLogger(newTestSimpleLogger(LevelInfo, out), AsyncOptions{}, &manualClock{})
+	defer logger.Close()
+	SetRoot(logger)
+
+	Info("message")
+	assert.NoError(Shutdown())
+	assert.Equal("0001-01-01 00:00:00.000 [INFO] - message\n", out.String())
+	assert.Equal(1, out.syncs)
+	assert.Equal(0, out.closes)
+}
+
+// failingWriter is a writer whose writes always fail.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriterLogger_WriteError(t *testing.T) {
+	assert := assert.New(t)
+
+	var errs []error
+	fallback := &bytes.Buffer{}
+	logger := newTestSimpleLogger(LevelInfo, failingWriter{})
+	logger.OnWriteError(func(err error) {
+		errs = append(errs, err)
+	})
+
+	logger.Info("lost")
+	logger.SetFallback(fallback)
+	logger.With("key", "value").Info("saved")
+
+	assert.Equal([]error{errors.New("disk full"), errors.New("disk full")}, errs)
+	assert.Equal(uint64(2), logger.FailedWrites(), "Derived loggers must count failed writes of the original logger")
+	assert.Equal("0001-01-01 00:00:00.000 [INFO] - saved key=value\n", fallback.String())
+
+	logger.SetOut(fallback)
+	logger.Info("written")
+	assert.Equal(uint64(2), logger.FailedWrites())
+}
+
+func TestColoredLogger_WriteError(t *testing.T) {
+	assert := assert.New(t)
+
+	errs := 0
+	fallback := &bytes.Buffer{}
+	logger := newTestColoredLogger(newTestSimpleLogger(LevelInfo, failingWriter{}))
+	logger.OnWriteError(func(error) {
+		errs++
+	})
+	logger.SetFallback(fallback)
+
+	logger.Info("message")
+	assert.Equal(1, errs, "The colored message must be written with a single write")
+	assert.Equal(uint64(1), logger.FailedWrites())
+	assert.Equal("0001-01-01 00:00:00.000 [INFO] - message\n", fallback.String(), "Color codes must not be written to the fallback")
+}